@@ -2,13 +2,25 @@
 package goaseprite
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/csv"
 	"errors"
+	"fmt"
+	"image"
+	"image/color"
 	"io"
 	"io/fs"
+	"math"
+	"math/rand"
+	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/tidwall/gjson"
 )
@@ -20,13 +32,47 @@ const (
 )
 
 const (
+	// Deprecated: Compare errors against ErrNoTagByName using errors.Is() instead.
 	ErrorNoTagByName = "no tags by name"
 )
 
+var (
+	ErrNoTagByName      = errors.New(ErrorNoTagByName)             // ErrNoTagByName is returned when trying to play or look up a tag that doesn't exist in a File.
+	ErrInvalidJSON      = errors.New("invalid Aseprite JSON data") // ErrInvalidJSON is returned when trying to read data that isn't valid Aseprite JSON.
+	ErrFrameOutOfRange  = errors.New("frame index out of range")   // ErrFrameOutOfRange is returned when a frame index is outside of the range it must be in.
+	ErrUnknownFrameName = errors.New("unknown frame name")         // ErrUnknownFrameName is returned when a Tag's bounds reference a frame by a name that doesn't exist in the JSON data.
+	ErrInvalidTagRecord = errors.New("invalid tag definition")     // ErrInvalidTagRecord is returned when a Tag definition read by File.LoadTagsFromReader() is malformed.
+)
+
+// DurationScale is the value that Read() multiplies the frame durations exported by Aseprite (which are in milliseconds) by.
+// It defaults to 0.001, giving durations in seconds; set it to 1 to keep durations in milliseconds, for example. Note that
+// Player.Update() expects its delta time argument to be in the same unit as the durations.
+var DurationScale = 0.001
+
+// ParseTagData controls whether Read() keeps the user data of Tags, so that it can be parsed into key:value pairs (e.g.
+// "speed:2;hold:1") with Tag.Meta(). When a Tag's user data contains a "speed" key, Players use it as a playback speed
+// multiplier for that Tag.
+// ParseTagData is false by default.
+var ParseTagData = false
+
+// TagIndexBase is the index that the "from" and "to" frame indices of Tags in the JSON data start counting from; Read()
+// subtracts it from them so that Tag.Start and Tag.End are always 0-based. It defaults to 0, which is what Aseprite exports.
+// TagIndexBase is only an interop setting for custom exporters that write 1-based Tag ranges (in which case, set it to 1);
+// leave it at 0 for files exported by Aseprite itself.
+var TagIndexBase = 0
+
 // Frame contains timing and position information for the frame on the spritesheet.
+// Aseprite doesn't export its border or padding settings in the JSON data; instead, the frame positions it exports (and so X
+// and Y) already account for any padding applied to the spritesheet, so they can be used as-is.
 type Frame struct {
+	Name     string // The name of the frame as exported from Aseprite (e.g. "exampleSprite 0.aseprite").
 	X, Y     int
-	Duration float32 // The duration of the frame in seconds.
+	W, H     int     // The size of the frame on the spritesheet; this can be smaller than the File's FrameWidth and FrameHeight if the frame was trimmed on export.
+	Duration float32 // The duration of the frame in seconds (or in another unit, if DurationScale has been changed).
+	Opacity  uint8   // The opacity of the frame (0-255), if it was exported; defaults to 255 (fully opaque).
+	// OffsetX and OffsetY are the frame's motion offset (e.g. root motion), read from the frame's "position" object if the
+	// export pipeline added one; otherwise, they're 0.
+	OffsetX, OffsetY int
 }
 
 // Slice represents a Slice (rectangle) that was defined in Aseprite and exported in the JSON file.
@@ -35,18 +81,104 @@ type Slice struct {
 	Data  string     // Data is blank by default, but can be specified on export from Aseprite to be whatever you need it to be.
 	Keys  []SliceKey // The individual keys (positions and sizes of Slices) according to the Frames they operate on.
 	Color int64
+	// IsNinePatch is true if the Slice was set up as a nine-patch (9-slice) in Aseprite, in which case its keys'
+	// NinePatchCenter rectangles are set. The center rectangle is read from a key's "center" object (as current versions
+	// of Aseprite export it), or from a "9slice" object (as some older versions did).
+	IsNinePatch bool
 }
 
 func (slice Slice) IsEmpty() bool {
 	return len(slice.Keys) == 0
 }
 
+// KeyAt returns the SliceKey that is active on the frame with the given index, and a boolean indicating if there is one.
+// A key stays active from its frame until the frame of the next key, so the active key is the last one whose Frame
+// is at or before the given frame index.
+func (slice Slice) KeyAt(frameIndex int) (SliceKey, bool) {
+
+	active := SliceKey{}
+	found := false
+
+	for _, key := range slice.Keys {
+		if int(key.Frame) <= frameIndex && (!found || key.Frame >= active.Frame) {
+			active = key
+			found = true
+		}
+	}
+
+	return active, found
+
+}
+
+// KeyDeltas returns the per-frame movement of the Slice's position (the top-left corner of its bounds), from the frame of its
+// first key to the frame of its last key. The element at index i is the movement from the frame i frames after the first
+// key's frame to the frame after that. Frames between keys use the active key (see KeyAt()), so they show no movement. If the
+// Slice has fewer than two keys, an empty slice is returned.
+func (slice Slice) KeyDeltas() []image.Point {
+
+	deltas := []image.Point{}
+
+	if len(slice.Keys) < 2 {
+		return deltas
+	}
+
+	first, last := int(slice.Keys[0].Frame), int(slice.Keys[0].Frame)
+	for _, key := range slice.Keys {
+		if int(key.Frame) < first {
+			first = int(key.Frame)
+		}
+		if int(key.Frame) > last {
+			last = int(key.Frame)
+		}
+	}
+
+	prev, _ := slice.KeyAt(first)
+
+	for frame := first + 1; frame <= last; frame++ {
+		key, _ := slice.KeyAt(frame)
+		deltas = append(deltas, image.Pt(key.X-prev.X, key.Y-prev.Y))
+		prev = key
+	}
+
+	return deltas
+
+}
+
+// DataMap parses the Slice's user Data into a map, for attaching metadata (like "type:hurtbox") to Slices. The Data can either
+// be of the form "key:value;key:value", or a JSON object (e.g. {"type": "hurtbox", "damage": 2}), in which case each value is
+// converted to a string. Malformed data results in an empty map.
+func (slice Slice) DataMap() map[string]string {
+
+	data := strings.TrimSpace(slice.Data)
+
+	if !strings.HasPrefix(data, "{") {
+		return parseDataPairs(data)
+	}
+
+	pairs := map[string]string{}
+
+	if gjson.Valid(data) {
+		gjson.Parse(data).ForEach(func(key, value gjson.Result) bool {
+			pairs[key.String()] = value.String()
+			return true
+		})
+	}
+
+	return pairs
+
+}
+
 // SliceKey represents a Slice's size and position in the Aseprite file on a specific frame. An individual Aseprite File can have multiple
 // Slices inside, which can also have multiple frames in which the Slice's position and size changes. The SliceKey's Frame indicates which
 // frame the key is operating on.
 type SliceKey struct {
-	Frame      int32
-	X, Y, W, H int
+	Frame          int32
+	X, Y, W, H     int
+	XF, YF, WF, HF float64 // The position and size of the key as floats, preserving fractional bounds (e.g. from scaled exports).
+
+	// NinePatchCenter is the center rectangle of a nine-patch Slice, relative to the key's position; empty if the Slice
+	// isn't a nine-patch.
+	NinePatchCenter image.Rectangle
 }
 
 // Center returns the center X and Y position of the Slice in the current key.
@@ -54,6 +186,22 @@ func (key SliceKey) Center() (int, int) {
 	return key.X + (key.W / 2), key.Y + (key.H / 2)
 }
 
+// mirrored returns the SliceKey mirrored horizontally and / or vertically within a frame of the given width and height.
+func (key SliceKey) mirrored(frameWidth, frameHeight int, horizontal, vertical bool) SliceKey {
+	center := key.NinePatchCenter
+	if horizontal {
+		key.X = frameWidth - key.X - key.W
+		key.XF = float64(frameWidth) - key.XF - key.WF
+		key.NinePatchCenter.Min.X, key.NinePatchCenter.Max.X = key.W-center.Max.X, key.W-center.Min.X
+	}
+	if vertical {
+		key.Y = frameHeight - key.Y - key.H
+		key.YF = float64(frameHeight) - key.YF - key.HF
+		key.NinePatchCenter.Min.Y, key.NinePatchCenter.Max.Y = key.H-center.Max.Y, key.H-center.Min.Y
+	}
+	return key
+}
+
 // Tag contains details regarding each tag or animation from Aseprite.
 // Start and End are the starting and ending frame of the Tag. Direction is a string, and can be assigned one of the playback constants.
 type Tag struct {
@@ -61,31 +209,167 @@ type Tag struct {
 	Start, End int
 	Direction  string
 	File       *File
+	Color      int64 // The color of the Tag in Aseprite, in RRGGBBAA format; 0 if the color wasn't exported.
+
+	data string // The Tag's raw user data, kept only if ParseTagData was true when the Tag was read; see Meta().
 }
 
 func (tag Tag) IsEmpty() bool {
 	return tag.File == nil
 }
 
-// Layer contains details regarding the layers exported from Aseprite, including the layer's name (string), opacity (0-255), and
-// blend mode (string).
+// Duration returns the total duration of the Tag in seconds, which is the sum of the durations of the Frames within it.
+func (tag Tag) Duration() float32 {
+	duration := float32(0)
+	for i := tag.Start; i <= tag.End; i++ {
+		duration += tag.File.Frames[i].Duration
+	}
+	return duration
+}
+
+// AverageFPS returns the average number of frames per second of the Tag, which is its number of frames divided by its total
+// Duration. If the Tag's Duration is 0, AverageFPS returns 0.
+func (tag Tag) AverageFPS() float32 {
+	duration := tag.Duration()
+	if duration <= 0 {
+		return 0
+	}
+	return float32(tag.End-tag.Start+1) / duration
+}
+
+// ThumbnailFrame returns the index of the Tag's middle frame, which usually makes for a good representative pose when
+// previewing the Tag (e.g. in an asset browser). For Tags with an even number of frames, the earlier of the two middle
+// frames is returned.
+func (tag Tag) ThumbnailFrame() int {
+	return tag.Start + (tag.End-tag.Start)/2
+}
+
+// playOrder returns the indices of the Tag's frames in the order they're shown during one loop when playing in the given
+// direction (for ping-pong, that's forward and then back, without repeating the frames at either end).
+func (tag Tag) playOrder(direction string) []int {
+
+	order := []int{}
+
+	if direction == PlayBackward {
+		for i := tag.End; i >= tag.Start; i-- {
+			order = append(order, i)
+		}
+		return order
+	}
+
+	for i := tag.Start; i <= tag.End; i++ {
+		order = append(order, i)
+	}
+
+	if direction == PlayPingPong {
+		for i := tag.End - 1; i > tag.Start; i-- {
+			order = append(order, i)
+		}
+	}
+
+	return order
+
+}
+
+// loopDuration returns the duration, in seconds, of one loop through the Tag when playing in the given direction. Unlike
+// Duration(), this accounts for the frames that ping-pong playback shows twice.
+func (tag Tag) loopDuration(direction string) float32 {
+	duration := float32(0)
+	for _, frameIndex := range tag.playOrder(direction) {
+		duration += tag.File.Frames[frameIndex].Duration
+	}
+	return duration
+}
+
+// frameAtTime returns the index of the frame that's shown at the given time (in seconds) after starting to play the Tag in the
+// given direction, looping indefinitely.
+func (tag Tag) frameAtTime(direction string, t float32) int {
+
+	order := tag.playOrder(direction)
+
+	loopDuration := tag.loopDuration(direction)
+
+	if loopDuration <= 0 || t < 0 {
+		return order[0]
+	}
+
+	t = float32(math.Mod(float64(t), float64(loopDuration)))
+
+	for _, frameIndex := range order {
+		t -= tag.File.Frames[frameIndex].Duration
+		if t < 0 {
+			return frameIndex
+		}
+	}
+
+	return order[len(order)-1]
+
+}
+
+// isDefault returns if the Tag is the default ("") Tag that spans all of the File's Frames.
+func (tag Tag) isDefault() bool {
+	return tag.Name == "" && tag.Start == 0 && tag.File != nil && tag.End == len(tag.File.Frames)-1
+}
+
+// equals returns if the Tag is the same as the other Tag.
+func (tag Tag) equals(other Tag) bool {
+	return tag.Name == other.Name && tag.Start == other.Start && tag.End == other.End && tag.Direction == other.Direction && tag.File == other.File
+}
+
+// Meta returns the key:value pairs parsed from the Tag's user data (e.g. "speed:2;hold:1"). It returns nil if the Tag has no
+// user data, or if ParseTagData wasn't set when the Tag was read. The pairs are parsed on each call, so the returned map can
+// be modified freely.
+func (tag Tag) Meta() map[string]string {
+	if tag.data == "" {
+		return nil
+	}
+	return parseDataPairs(tag.data)
+}
+
+// speed returns the playback speed multiplier specified by the "speed" key in the Tag's user data, or 1 if there is none.
+// Speeds of 0 or less (which would freeze playback or run it backwards past the Tag's bounds) are invalid, and also give 1.
+func (tag Tag) speed() float32 {
+	if value, exists := dataValue(tag.data, "speed"); exists {
+		if speed, err := strconv.ParseFloat(value, 32); err == nil && speed > 0 && !math.IsInf(speed, 1) {
+			return float32(speed)
+		}
+	}
+	return 1
+}
+
+// Layer contains details regarding the layers exported from Aseprite, including the layer's name (string), opacity (0-255),
+// blend mode (string), and type (string).
 type Layer struct {
 	Name      string
 	Opacity   uint8
 	BlendMode string
+	Type      string // The type of the layer (e.g. "normal", "group", or "tilemap"); defaults to "normal" if not specified in the JSON.
+	Group     string // The name of the group layer containing this layer; blank if the layer isn't in a group.
+	Color     int64  // The color tag of the layer in Aseprite's UI, in RRGGBBAA format; 0 if the layer has no color tag.
+}
+
+// LayerNode is a node in a File's tree of Layers, as returned by File.LayerTree(). It holds a Layer, along with the nodes of
+// the Layers within it (if it's a group layer).
+type LayerNode struct {
+	Layer    Layer
+	Children []*LayerNode
 }
 
 // File contains all properties of an exported aseprite file. ImagePath is the absolute path to the image as reported by the exported
 // Aseprite JSON data. Path is the string used to open the File if it was opened with the Open() function; otherwise, it's blank.
 type File struct {
 	Path                    string  // Path to the file (exampleSprite.json); blank if the *File was loaded using Read().
-	ImagePath               string  // Path to the image associated with the Aseprite file (exampleSprite.png).
-	Width, Height           int32   // Overall width and height of the File.
-	FrameWidth, FrameHeight int32   // Width and height of the frames in the File.
+	ImagePath               string  // Path to the image associated with the Aseprite file (exampleSprite.png). If the image is referenced by a URL, it's left as-is.
+	Width, Height           int32   // Overall width and height of the File's spritesheet image.
+	Format                  string  // The pixel format of the spritesheet image, as exported by Aseprite (e.g. "RGBA8888", or "I8" for indexed images).
+	FrameWidth, FrameHeight int32   // Width and height of the frames in the File (i.e. the canvas size in Aseprite).
 	Frames                  []Frame // The animation Frames present in the File.
 	Tags                    []Tag   // A map of Tags, with their names being the keys.
-	Layers                  []Layer // A slice of Layers.
+	Layers                  []Layer // A slice of Layers, ordered from the bottom-most to the top-most, as exported by Aseprite.
 	Slices                  []Slice // A slice of the Slices present in the file.
+
+	contentBounds     map[int]image.Rectangle // Cached results of ContentBounds(), by frame index.
+	contentBoundsLock sync.Mutex              // Guards contentBounds, as a File can be shared by Players on different goroutines.
 }
 
 // SliceByName returns a Slice that has the name specified and a boolean indicating whether it could be found or not.
@@ -105,6 +389,12 @@ func (file *File) HasSlice(sliceName string) bool {
 	return exists
 }
 
+// ImageIsURL returns if the File's ImagePath is a URL (e.g. "https://example.com/sprite.png"), rather than a path on the
+// filesystem.
+func (file *File) ImageIsURL() bool {
+	return isURL(file.ImagePath)
+}
+
 // TagByName returns a Tag by the name specified, and if the Tag was found.
 func (file *File) TagByName(tagName string) (Tag, bool) {
 	for _, t := range file.Tags {
@@ -121,257 +411,2376 @@ func (file *File) HasTag(tagName string) bool {
 	return exists
 }
 
-// Player is an animation player for Aseprite files.
-type Player struct {
-	File           *File
-	PlaySpeed      float32 // The playback speed; altering this can be used to globally slow down or speed up animation playback.
-	CurrentTag     Tag     // The currently playing animation.
-	FrameIndex     int     // The current frame of the File's animation / tag playback.
-	PrevFrameIndex int     // The previous frame in the playback.
-	frameCounter   float32
+// Thumbnails returns the thumbnail frame (see Tag.ThumbnailFrame()) of each of the File's Tags, with the Tags' names being
+// the keys. As with TagMap(), if multiple Tags share a name, the last Tag by that name in the File wins.
+func (file *File) Thumbnails() map[string]int {
+	thumbnails := make(map[string]int, len(file.Tags))
+	for _, t := range file.Tags {
+		thumbnails[t.Name] = t.ThumbnailFrame()
+	}
+	return thumbnails
+}
 
-	// Callbacks
-	OnLoop        func()        // OnLoop gets called when the playing animation / tag does a complete loop. For a ping-pong animation, this is a full forward + back cycle.
-	OnFrameChange func()        // OnFrameChange gets called when the playing animation / tag changes frames.
-	OnTagEnter    func(tag Tag) // OnTagEnter gets called when entering a tag from "outside" of it (i.e. if not playing a tag and then it gets played, this gets called, or if you're playing a tag and you pass through another tag).
-	OnTagExit     func(tag Tag) // OnTagExit gets called when exiting a tag from inside of it (i.e. if you finish passing through a tag while playing another one).
+// HasNamedTags returns if the File has any Tags other than the default ("") Tag that covers the whole File.
+func (file *File) HasNamedTags() bool {
+	for _, t := range file.Tags {
+		if !t.isDefault() {
+			return true
+		}
+	}
+	return false
+}
 
-	playDirection int
+// TagMap returns the File's Tags as a map, with their names being the keys. The map is built fresh on each call, so it
+// reflects the File's current Tags. Note that Aseprite allows multiple Tags to share a name; in that case, the last Tag
+// by that name in the File wins.
+func (file *File) TagMap() map[string]Tag {
+	tags := make(map[string]Tag, len(file.Tags))
+	for _, t := range file.Tags {
+		tags[t.Name] = t
+	}
+	return tags
 }
 
-// CreatePlayer returns a new animation player that plays animations from a given Aseprite file.
-func (file *File) CreatePlayer() *Player {
-	return &Player{
-		File:      file,
-		PlaySpeed: 1,
+// CanvasSize returns the logical size of each frame (the size of the canvas in Aseprite), as opposed to the size of the
+// spritesheet image, which is stored in Width and Height.
+func (file *File) CanvasSize() (int, int) {
+	return int(file.FrameWidth), int(file.FrameHeight)
+}
+
+// FilterLayers returns the File's Layers for which the given function returns true, in their original order.
+func (file *File) FilterLayers(keep func(layer Layer) bool) []Layer {
+	layers := []Layer{}
+	for _, layer := range file.Layers {
+		if keep(layer) {
+			layers = append(layers, layer)
+		}
 	}
+	return layers
 }
 
-// Clone clones the Player.
-func (player *Player) Clone() *Player {
-	newPlayer := player.File.CreatePlayer()
-	newPlayer.PlaySpeed = player.PlaySpeed
-	newPlayer.CurrentTag = player.CurrentTag
-	newPlayer.FrameIndex = player.FrameIndex
-	newPlayer.frameCounter = player.frameCounter
+// LayersExcept returns the File's Layers, excluding any Layers with one of the given names (e.g. "reference" or "guides").
+func (file *File) LayersExcept(names ...string) []Layer {
+	return file.FilterLayers(func(layer Layer) bool {
+		for _, name := range names {
+			if layer.Name == name {
+				return false
+			}
+		}
+		return true
+	})
+}
 
-	newPlayer.OnLoop = player.OnLoop
-	newPlayer.OnFrameChange = player.OnFrameChange
-	newPlayer.OnTagEnter = player.OnTagEnter
-	newPlayer.OnTagExit = player.OnTagExit
+// LayersInDrawOrder returns a copy of the File's Layers in the order they should be drawn in to composite them as Aseprite does;
+// that's from the bottom-most Layer to the top-most, which is the order Aseprite exports them in.
+func (file *File) LayersInDrawOrder() []Layer {
+	return append([]Layer{}, file.Layers...)
+}
 
-	return newPlayer
+// LayersTopToBottom returns a copy of the File's Layers from the top-most to the bottom-most, as they're displayed in Aseprite's
+// layer panel; this is the reverse of LayersInDrawOrder().
+func (file *File) LayersTopToBottom() []Layer {
+	layers := make([]Layer, 0, len(file.Layers))
+	for i := len(file.Layers) - 1; i >= 0; i-- {
+		layers = append(layers, file.Layers[i])
+	}
+	return layers
 }
 
-// Play sets the specified tag name up to be played back. A tagName of "" will play back the entire file.
-func (player *Player) Play(tagName string) error {
+// LayerTree returns the File's Layers as a tree, built from the Layers' Group fields; the returned nodes are the Layers that
+// aren't within any group, and each group layer's node holds the nodes of the Layers within it. Layers keep their original
+// order at each level of the tree. Layers whose group can't be found are treated as being at the root.
+func (file *File) LayerTree() []*LayerNode {
 
-	exists := false
+	nodes := make([]*LayerNode, len(file.Layers))
+	byName := map[string]*LayerNode{}
 
-	for _, anim := range player.File.Tags {
+	for i, layer := range file.Layers {
+		nodes[i] = &LayerNode{Layer: layer}
+		if _, exists := byName[layer.Name]; !exists {
+			byName[layer.Name] = nodes[i]
+		}
+	}
 
-		if anim.Name == tagName {
+	roots := []*LayerNode{}
 
-			exists = true
+	for _, node := range nodes {
+		if parent, exists := byName[node.Layer.Group]; exists && node.Layer.Group != "" && parent != node {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
 
-			if anim != player.CurrentTag {
+	return roots
 
-				if !player.CurrentTag.IsEmpty() {
-					player.PrevFrameIndex = -1
-				} else {
-					player.PrevFrameIndex = player.FrameIndex
-				}
+}
 
-				player.CurrentTag = anim
-				player.frameCounter = 0
+// TagsByStart returns a copy of the File's Tags, sorted in ascending order by their Start frames (and then by their End
+// frames for Tags that start on the same frame).
+func (file *File) TagsByStart() []Tag {
 
-				if anim.Direction == PlayBackward {
-					player.playDirection = -1
-					player.FrameIndex = player.CurrentTag.End
-				} else {
-					player.playDirection = 1
-					player.FrameIndex = player.CurrentTag.Start
-				}
+	tags := append([]Tag{}, file.Tags...)
 
-				player.pollTagChanges()
+	sort.SliceStable(tags, func(i, j int) bool {
+		if tags[i].Start == tags[j].Start {
+			return tags[i].End < tags[j].End
+		}
+		return tags[i].Start < tags[j].Start
+	})
 
-			}
+	return tags
 
-			break
+}
 
-		}
-	}
+// LongestTag returns the named Tag (i.e. excluding the default ("") Tag) with the most frames, and a boolean indicating if the
+// File has any named Tags. If multiple Tags have the most frames, the first one in the File is returned.
+func (file *File) LongestTag() (Tag, bool) {
 
-	if !exists {
-		return errors.New(ErrorNoTagByName)
+	longest := Tag{}
+	found := false
+
+	for _, tag := range file.Tags {
+		if !tag.isDefault() && (!found || tag.End-tag.Start > longest.End-longest.Start) {
+			longest = tag
+			found = true
+		}
 	}
 
-	return nil
+	return longest, found
 
 }
 
-// Update updates the currently playing animation. dt is the delta value between the previous frame and the current frame.
-func (player *Player) Update(dt float32) {
+// OrphanFrames returns the indices of the File's Frames that aren't within any of its named Tags (i.e. excluding the default
+// ("") Tag), in ascending order.
+func (file *File) OrphanFrames() []int {
 
-	anim := player.CurrentTag
+	orphans := []int{}
 
-	if !anim.IsEmpty() {
+	for i := range file.Frames {
 
-		player.frameCounter += dt * player.PlaySpeed
+		orphan := true
+
+		for _, tag := range file.Tags {
+			if !tag.isDefault() && i >= tag.Start && i <= tag.End {
+				orphan = false
+				break
+			}
+		}
 
-		frameDur := player.File.Frames[player.FrameIndex].Duration
+		if orphan {
+			orphans = append(orphans, i)
+		}
 
-		for player.frameCounter >= frameDur {
+	}
 
-			player.frameCounter -= frameDur
+	return orphans
 
-			player.PrevFrameIndex = player.FrameIndex
+}
 
-			player.FrameIndex += player.playDirection
+// FrameNames returns the names of the File's Frames, as exported from Aseprite, in frame order.
+func (file *File) FrameNames() []string {
+	names := make([]string, 0, len(file.Frames))
+	for _, frame := range file.Frames {
+		names = append(names, frame.Name)
+	}
+	return names
+}
 
-			if anim.Direction == PlayPingPong {
+// ScaleDurations multiplies the duration of each of the File's Frames by the given factor, so a factor of 2 makes all of the
+// File's animations play at half speed. Unlike Player.PlaySpeed, this affects all Players of the File. Factors of 0 or less
+// are ignored.
+func (file *File) ScaleDurations(factor float32) {
 
-				if player.FrameIndex > anim.End {
-					player.FrameIndex = anim.End - 1
-					player.playDirection *= -1
-				} else if player.FrameIndex < anim.Start {
-					player.FrameIndex = anim.Start + 1
-					player.playDirection *= -1
-					if player.OnLoop != nil {
-						player.OnLoop()
-					}
-				}
+	if factor <= 0 {
+		return
+	}
 
-			} else if player.playDirection > 0 && player.FrameIndex > anim.End {
-				player.FrameIndex -= anim.End - anim.Start + 1
-				if player.OnLoop != nil {
-					player.OnLoop()
-				}
-			} else if player.playDirection < 0 && player.FrameIndex < anim.Start {
-				player.FrameIndex += anim.End - anim.Start + 1
-				if player.OnLoop != nil {
-					player.OnLoop()
-				}
-			}
+	for i := range file.Frames {
+		file.Frames[i].Duration *= factor
+	}
 
-			if player.FrameIndex != player.PrevFrameIndex && player.OnFrameChange != nil {
-				player.OnFrameChange()
-			}
+}
 
-			player.pollTagChanges()
+// Optimize returns a new File in which runs of consecutive duplicate Frames (Frames with the same rectangle on the
+// spritesheet, duration, opacity, and motion offset) are merged into single Frames lasting as long as the whole run, with the
+// Tags' ranges and the Slices' key frames remapped to match. Frames are only merged if doing so doesn't change any Tag
+// boundaries, Slice keys, or ping-pong timing, so the optimized File plays back identically to the original.
+func (file *File) Optimize() *File {
+
+	optimized := &File{
+		Path:        file.Path,
+		ImagePath:   file.ImagePath,
+		Width:       file.Width,
+		Height:      file.Height,
+		Format:      file.Format,
+		FrameWidth:  file.FrameWidth,
+		FrameHeight: file.FrameHeight,
+		Layers:      append([]Layer{}, file.Layers...),
+	}
 
+	// remap maps the index of each of the File's Frames to its index in the optimized File.
+	remap := make([]int, len(file.Frames))
+
+	for i, frame := range file.Frames {
+
+		if i > 0 && file.canMergeFrames(i-1, i) {
+			last := &optimized.Frames[len(optimized.Frames)-1]
+			last.Duration += frame.Duration
+		} else {
+			optimized.Frames = append(optimized.Frames, frame)
 		}
 
-	}
+		remap[i] = len(optimized.Frames) - 1
 
-}
+	}
 
-// TouchingTags returns the tags currently being touched by the Player (tag).
-func (player *Player) TouchingTags() []Tag {
-	tags := []Tag{}
-	for _, t := range player.File.Tags {
-		if player.FrameIndex >= t.Start && player.FrameIndex <= t.End {
-			tags = append(tags, t)
+	for _, tag := range file.Tags {
+		// Bounds outside of the File's Frames (like the default Tag's End of a File with no Frames) are left as they are.
+		if tag.Start >= 0 && tag.Start < len(remap) {
+			tag.Start = remap[tag.Start]
+		}
+		if tag.End >= 0 && tag.End < len(remap) {
+			tag.End = remap[tag.End]
 		}
+		tag.File = optimized
+		optimized.Tags = append(optimized.Tags, tag)
 	}
-	return tags
-}
 
-// TouchingTagByName returns if a tag by the given name is being touched by the Player (tag).
-func (player *Player) TouchingTagByName(tagName string) bool {
-	for _, t := range player.File.Tags {
-		if t.Name == tagName && player.FrameIndex >= t.Start && player.FrameIndex <= t.End {
-			return true
+	for _, slice := range file.Slices {
+		keys := make([]SliceKey, 0, len(slice.Keys))
+		for _, key := range slice.Keys {
+			if int(key.Frame) >= 0 && int(key.Frame) < len(remap) {
+				key.Frame = int32(remap[key.Frame])
+			}
+			keys = append(keys, key)
 		}
+		slice.Keys = keys
+		optimized.Slices = append(optimized.Slices, slice)
 	}
-	return false
+
+	return optimized
+
 }
 
-// pollTagChanges polls the File for tag changes (entering or exiting Tags).
-func (player *Player) pollTagChanges() {
+// canMergeFrames returns if the consecutive Frames with the given indices are duplicates that can be merged without changing
+// any Tag boundaries, Slice keys, or the timing of ping-pong Tags.
+func (file *File) canMergeFrames(prev, next int) bool {
 
-	if player.OnTagExit != nil {
-		for _, tag := range player.File.Tags {
-			if (player.PrevFrameIndex >= tag.Start && player.PrevFrameIndex <= tag.End) && (player.FrameIndex < tag.Start || player.FrameIndex > tag.End) {
-				player.OnTagExit(tag)
-			}
+	a, b := file.Frames[prev], file.Frames[next]
+
+	if a.X != b.X || a.Y != b.Y || a.W != b.W || a.H != b.H || a.Duration != b.Duration || a.Opacity != b.Opacity || a.OffsetX != b.OffsetX || a.OffsetY != b.OffsetY {
+		return false
+	}
+
+	for _, tag := range file.Tags {
+		if tag.Start == next || tag.End == prev {
+			return false
+		}
+		// A ping-pong cycle shows its Start and End frames once, but the frames between them twice, so merging a run into
+		// either end would change the cycle's length.
+		if tag.Direction == PlayPingPong && (tag.End == next || tag.Start == prev) {
+			return false
 		}
 	}
 
-	if player.OnTagEnter != nil {
-		for _, tag := range player.File.Tags {
-			if (player.PrevFrameIndex < tag.Start || player.PrevFrameIndex > tag.End) && (player.FrameIndex >= tag.Start && player.FrameIndex <= tag.End) {
-				player.OnTagEnter(tag)
+	for _, slice := range file.Slices {
+		for _, key := range slice.Keys {
+			if int(key.Frame) == next {
+				return false
 			}
 		}
 	}
 
+	return true
+
 }
 
-// CurrentFrame returns the current frame for the currently playing Tag in the File and a boolean indicating if the Player is playing a Tag or not.
-func (player *Player) CurrentFrame() (Frame, bool) {
-	if !player.CurrentTag.IsEmpty() {
-		return player.File.Frames[player.FrameIndex], true
+// RequireTags returns an error naming the first of the given tags that the File doesn't have, or nil if the File has all of
+// them. This is useful for checking that a sprite has all of the tags that your code expects to play when loading it.
+func (file *File) RequireTags(names ...string) error {
+	for _, name := range names {
+		if !file.HasTag(name) {
+			return fmt.Errorf("%w: %q", ErrNoTagByName, name)
+		}
 	}
-	return Frame{}, false
+	return nil
 }
 
-// CurrentFrameCoords returns the four corners of the current frame, of format (x1, y1, x2, y2). If File.CurrentFrame() is nil, it will instead
-// return all -1's.
-func (player *Player) CurrentFrameCoords() (int, int, int, int) {
+// TagFrameRect returns the rectangle on the spritesheet of the frame at the given index within the tag of the given name
+// (so an indexInTag of 0 is the tag's Start frame), and a boolean indicating if the tag exists and the index is within it.
+func (file *File) TagFrameRect(tagName string, indexInTag int) (image.Rectangle, bool) {
 
-	if frame, ok := player.CurrentFrame(); ok {
-		return frame.X, frame.Y, frame.X + int(player.File.FrameWidth), frame.Y + int(player.File.FrameHeight)
+	tag, exists := file.TagByName(tagName)
+
+	if !exists || indexInTag < 0 || tag.Start+indexInTag > tag.End {
+		return image.Rectangle{}, false
 	}
 
-	return -1, -1, -1, -1
+	return file.frameRect(tag.Start + indexInTag), true
 
 }
 
-// CurrentUVCoords returns the top-left corner of the current frame, of format (x, y). If File.CurrentFrame() is nil, it will instead
-// return (-1, -1).
-func (player *Player) CurrentUVCoords() (float64, float64) {
+// FrameRectAtTime returns the rectangle on the spritesheet of the frame shown t seconds after starting to play the tag of the
+// given name (looping, and according to the tag's Direction and speed), and if the tag was found. This allows rendering
+// animations from a shared clock without keeping a Player around for each instance.
+func (file *File) FrameRectAtTime(tagName string, t float32) (image.Rectangle, bool) {
 
-	if frame, ok := player.CurrentFrame(); ok {
-		return float64(frame.X) / float64(player.File.Width), float64(frame.Y) / float64(player.File.Height)
+	tag, exists := file.TagByName(tagName)
+
+	if !exists || tag.End < tag.Start {
+		return image.Rectangle{}, false
 	}
 
-	return -1, -1
+	tag.File = file
+
+	return file.frameRect(tag.frameAtTime(tag.Direction, t*tag.speed())), true
 
 }
 
-// SetFrameIndexInAnimation sets the currently visible frame to frameIndex, using the playing animation as the range.
-// This means calling SetFrameIndexInAnimation with a frameIndex of 2 would set it to the third frame of the animation that is currently playing.
-func (player *Player) SetFrameIndexInAnimation(frameIndex int) {
+// TagSliceUnion returns the union of the bounds of the Slice of the given name across all of the frames within the tag of the
+// given name (using the key active on each frame, as returned by Slice.KeyAt()). This is useful as a single, conservative
+// bounding box for an entire animation. If the tag or Slice doesn't exist, an empty rectangle is returned.
+func (file *File) TagSliceUnion(tagName, sliceName string) image.Rectangle {
 
-	if !player.CurrentTag.IsEmpty() {
+	union := image.Rectangle{}
 
-		player.FrameIndex = player.CurrentTag.Start + frameIndex
-		if player.FrameIndex > player.CurrentTag.End {
-			player.FrameIndex = player.CurrentTag.End
-		}
+	tag, tagExists := file.TagByName(tagName)
+	slice, sliceExists := file.SliceByName(sliceName)
+
+	if !tagExists || !sliceExists {
+		return union
+	}
+
+	for i := tag.Start; i <= tag.End; i++ {
+		if key, ok := slice.KeyAt(i); ok {
+			union = union.Union(image.Rect(key.X, key.Y, key.X+key.W, key.Y+key.H))
+		}
+	}
+
+	return union
+
+}
+
+// frameRect returns the rectangle of the frame with the given index on the spritesheet.
+func (file *File) frameRect(frameIndex int) image.Rectangle {
+	frame := file.Frames[frameIndex]
+	w, h := frame.W, frame.H
+	if w <= 0 || h <= 0 {
+		w, h = int(file.FrameWidth), int(file.FrameHeight)
+	}
+	return image.Rect(frame.X, frame.Y, frame.X+w, frame.Y+h)
+}
+
+// ContentBounds returns the smallest rectangle enclosing the non-transparent pixels of the frame with the given index, relative
+// to the frame's top-left corner (so a frame with a 2-pixel transparent margin all around has bounds starting at (2, 2)). img
+// should be the File's spritesheet image. This is useful for tight collision boxes on sprites without authored Slices. If the
+// frame is fully transparent or the index is out of range, an empty rectangle is returned. Results are cached per frame, so
+// subsequent calls for the same frame are cheap; this assumes the same spritesheet image is passed each time. ContentBounds
+// is safe for concurrent use.
+func (file *File) ContentBounds(img image.Image, frameIndex int) image.Rectangle {
+
+	if frameIndex < 0 || frameIndex >= len(file.Frames) {
+		return image.Rectangle{}
+	}
+
+	file.contentBoundsLock.Lock()
+	defer file.contentBoundsLock.Unlock()
+
+	if bounds, cached := file.contentBounds[frameIndex]; cached {
+		return bounds
+	}
+
+	bounds := contentBounds(img, file.frameRect(frameIndex))
+
+	if file.contentBounds == nil {
+		file.contentBounds = map[int]image.Rectangle{}
+	}
+	file.contentBounds[frameIndex] = bounds
+
+	return bounds
+
+}
+
+// contentBounds scans img within frameRect and returns the smallest rectangle enclosing its non-transparent pixels,
+// relative to frameRect's top-left corner. It doesn't cache anything.
+func contentBounds(img image.Image, frameRect image.Rectangle) image.Rectangle {
+
+	area := frameRect.Intersect(img.Bounds())
+	bounds := image.Rectangle{}
+
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			if _, _, _, a := img.At(x, y).RGBA(); a > 0 {
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+
+	if !bounds.Empty() {
+		bounds = bounds.Sub(frameRect.Min)
+	}
+
+	return bounds
+
+}
+
+// EstimatedImageBytes returns a rough estimate of how much memory the File's spritesheet image takes up as an RGBA
+// texture (4 bytes per pixel), for budgeting purposes.
+func (file *File) EstimatedImageBytes() int {
+	return int(file.Width) * int(file.Height) * 4
+}
+
+// EstimatedFormatBytes returns a rough estimate of how much memory the File's spritesheet image takes up in the pixel Format
+// it was exported in (e.g. 1 byte per pixel for indexed "I8" images). Unknown formats are assumed to be RGBA, as with
+// EstimatedImageBytes().
+func (file *File) EstimatedFormatBytes() int {
+
+	bytesPerPixel := 4
+
+	switch file.Format {
+	case "I8":
+		bytesPerPixel = 1
+	case "GA88":
+		bytesPerPixel = 2
+	}
+
+	return int(file.Width) * int(file.Height) * bytesPerPixel
+
+}
+
+// AllFrameRects returns the rectangles of all of the File's Frames on the spritesheet, in order. If the frames were
+// trimmed on export, each rectangle is the size of its trimmed frame.
+func (file *File) AllFrameRects() []image.Rectangle {
+	rects := make([]image.Rectangle, len(file.Frames))
+	for i := range file.Frames {
+		rects[i] = file.frameRect(i)
+	}
+	return rects
+}
+
+// Fingerprint returns a hash of the File's structure (its dimensions, Frames, Tags, and Slices) as a hexadecimal string.
+// The fingerprint is deterministic, so two Files loaded from the same data always have the same fingerprint, which makes
+// it useful for caching assets and detecting changes to them.
+func (file *File) Fingerprint() string {
+
+	hash := sha256.New()
+
+	fmt.Fprintf(hash, "size:%d,%d;frame:%d,%d\n", file.Width, file.Height, file.FrameWidth, file.FrameHeight)
+
+	for _, frame := range file.Frames {
+		fmt.Fprintf(hash, "frame:%d,%d,%d,%d,%g,%d,%d,%d\n", frame.X, frame.Y, frame.W, frame.H, frame.Duration, frame.Opacity, frame.OffsetX, frame.OffsetY)
+	}
+
+	for _, tag := range file.Tags {
+		fmt.Fprintf(hash, "tag:%q,%d,%d,%q\n", tag.Name, tag.Start, tag.End, tag.Direction)
+		meta := tag.Meta()
+		keys := make([]string, 0, len(meta))
+		for key := range meta {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(hash, "meta:%q,%q\n", key, meta[key])
+		}
+	}
+
+	for _, slice := range file.Slices {
+		fmt.Fprintf(hash, "slice:%q,%q,%t\n", slice.Name, slice.Data, slice.IsNinePatch)
+		for _, key := range slice.Keys {
+			fmt.Fprintf(hash, "key:%d,%g,%g,%g,%g,%v\n", key.Frame, key.XF, key.YF, key.WF, key.HF, key.NinePatchCenter)
+		}
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+
+}
+
+// EventType indicates what happened in an Event.
+type EventType int
+
+const (
+	EventFrameChange EventType = iota // The Player changed frames.
+	EventTagEnter                     // The Player entered a tag; the Event's Tag is the tag entered.
+	EventTagExit                      // The Player exited a tag; the Event's Tag is the tag exited.
+	EventLoop                         // The playing tag looped.
+)
+
+// EventBufferSize is the number of Events the channel returned by Player.EventChannel() can hold before further Events are dropped.
+const EventBufferSize = 64
+
+// Event describes a change in a Player's playback state, as sent through the channel returned by Player.EventChannel().
+type Event struct {
+	Type       EventType
+	FrameIndex int // The index of the Player's current frame when the Event happened.
+	Tag        Tag // The tag the Event concerns; for EventFrameChange and EventLoop, this is the playing tag.
+}
+
+// Player is an animation player for Aseprite files.
+type Player struct {
+	File                 *File
+	PlaySpeed            float32 // The playback speed; altering this can be used to globally slow down or speed up animation playback.
+	CurrentTag           Tag     // The currently playing animation.
+	FrameIndex           int     // The current frame of the File's animation / tag playback.
+	PrevFrameIndex       int     // The previous frame in the playback.
+	Loops                int     // The number of times a tag plays before finishing; 0 (the default) loops the tag indefinitely.
+	EndHold              float32 // How long, in seconds, the last frame of a finishing tag is held before the tag finishes (see Loops).
+	FallbackToDefaultTag bool    // If true, Play() falls back to playing the default ("") tag when given an unknown tag name (still returning ErrNoTagByName), rather than keeping the current tag playing. Defaults to false.
+	FallbackTag          string  // The name of a tag that Play() plays instead when given an unknown tag name (still returning ErrNoTagByName); this takes precedence over FallbackToDefaultTag. Blank by default.
+	AutoPauseOnFinish    bool    // If true, the Player pauses itself when the playing tag finishes (see Loops), so Update() does nothing until Resume() is called or another tag is played. Defaults to false.
+	SkipEmptyFrames      bool    // If true and an image has been set with SetImage(), Update() skips over fully transparent frames (see File.ContentBounds()) without calling any frame callbacks for them, and timing queries like RemainingTime() leave them out. Defaults to false.
+	ClampFrameToTag      bool    // If true, Update() clamps FrameIndex into the current tag's range before advancing, guarding against FrameIndex being set outside of it. Defaults to false.
+	frameCounter         float32
+
+	// Render state; the Player doesn't render anything itself, but slice queries (like CurrentSliceKeys()) are mirrored
+	// within the frame according to FlipH and FlipV so that they match the sprite as it's drawn.
+	FlipH, FlipV bool
+	TintColor    color.RGBA
+
+	// Callbacks
+	OnLoop        func()        // OnLoop gets called when the playing animation / tag does a complete loop. For a ping-pong animation, this is a full forward + back cycle.
+	OnFrameChange func()        // OnFrameChange gets called when the playing animation / tag changes frames.
+	OnTagEnter    func(tag Tag) // OnTagEnter gets called when entering a tag from "outside" of it (i.e. if not playing a tag and then it gets played, this gets called, or if you're playing a tag and you pass through another tag).
+	OnTagExit     func(tag Tag) // OnTagExit gets called when exiting a tag from inside of it (i.e. if you finish passing through a tag while playing another one).
+	// OnFrameChangeTimed gets called when the playing animation / tag changes frames, like OnFrameChange, but also receives the index of the new frame and how far
+	// into the current Update() call's dt (in seconds) the frame change happened, for scheduling events (like sounds) with sub-tick precision.
+	OnFrameChangeTimed func(frameIndex int, timeIntoTick float32)
+	// OnDirectionChange gets called when a ping-pong animation (or a sequence started with PlaySequencePingPong()) turns
+	// around, with the new play direction (1 for forward, -1 for backward).
+	OnDirectionChange func(newDirection int)
+	// OnUpdate gets called at the end of every Update() call (after playback has advanced) with the call's dt, whether or not
+	// the frame changed (and even while the Player is paused), for per-tick logic tied to the animation.
+	OnUpdate func(dt float32)
+	OnFinish func() // OnFinish gets called when the playing animation / tag finishes after looping Loops times (and holding its last frame for EndHold seconds).
+
+	playDirection       int
+	tagDirection        string       // The direction the current tag is played in; usually the tag's Direction, unless overridden by PlayEx().
+	playOptions         *PlayOptions // The options the current tag was played with by PlayEx(), if any; cleared whenever a tag starts playing.
+	loopCount           int
+	holding             bool
+	finished            bool
+	paused              bool
+	autoPaused          bool // Whether the Player was paused by AutoPauseOnFinish, in which case playing a new tag resumes it.
+	justLooped          bool
+	justChangedFrame    bool
+	framesCrossed       []int
+	totalFramesPlayed   int
+	reachFrameCallbacks map[int][]func()
+	events              chan Event
+	onFinishOnce        func()
+	bookmarks           map[string]bookmark
+
+	sequence      []Tag
+	sequenceIndex int
+
+	easing       bool
+	easeTime     float32
+	easeDuration float32
+	easeSpeed    float32 // The PlaySpeed at the start of the ease, which is restored once the ease is over.
+
+	crossfade         *Player
+	crossfadeTime     float32
+	crossfadeDuration float32
+
+	rng *rand.Rand
+
+	img         image.Image
+	emptyFrames map[int]bool // Whether each frame of img is fully transparent; filled in lazily for SkipEmptyFrames
+}
+
+// bookmark is a playback position saved with Player.Bookmark().
+type bookmark struct {
+	tag           Tag
+	frameIndex    int
+	frameCounter  float32
+	playDirection int
+	tagDirection  string
+}
+
+// LoadTagsFromReader reads Tag definitions from CSV or TSV data, with one Tag per line in the form "name,start,end,direction"
+// (or the same separated by tabs), and merges them into the File's Tags: a defined Tag replaces any existing Tag by the same
+// name, and is otherwise added. The direction can be left out, in which case it defaults to PlayForward. Blank lines, lines
+// starting with "#", and a header line starting with "name" are skipped. This allows defining animation ranges outside of
+// Aseprite. If any line is malformed, an error wrapping ErrInvalidTagRecord is returned, and if any line has a frame range
+// outside of the File's Frames, an error wrapping ErrFrameOutOfRange is returned; either way, the File's Tags are left unchanged.
+func (file *File) LoadTagsFromReader(r io.Reader) error {
+
+	data, err := io.ReadAll(r)
+
+	if err != nil {
+		return err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// Tab-separated data is detected by it having more tabs than commas.
+	if strings.Count(string(data), "\t") > strings.Count(string(data), ",") {
+		reader.Comma = '\t'
+	}
+
+	records, err := reader.ReadAll()
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidTagRecord, err)
+	}
+
+	tags := []Tag{}
+
+	for i, record := range records {
+
+		if i == 0 && strings.EqualFold(strings.TrimSpace(record[0]), "name") {
+			continue
+		}
+
+		if len(record) < 3 || len(record) > 4 {
+			return fmt.Errorf("tag record %d: %w: expected name, start, end, and optionally direction", i+1, ErrInvalidTagRecord)
+		}
+
+		start, err := strconv.Atoi(strings.TrimSpace(record[1]))
+		if err != nil {
+			return fmt.Errorf("tag record %d: %w: invalid start frame: %v", i+1, ErrInvalidTagRecord, err)
+		}
+
+		end, err := strconv.Atoi(strings.TrimSpace(record[2]))
+		if err != nil {
+			return fmt.Errorf("tag record %d: %w: invalid end frame: %v", i+1, ErrInvalidTagRecord, err)
+		}
+
+		if start < 0 || start > end || end >= len(file.Frames) {
+			return fmt.Errorf("tag record %d: %w: %d-%d", i+1, ErrFrameOutOfRange, start, end)
+		}
+
+		direction := PlayForward
+		if len(record) == 4 && strings.TrimSpace(record[3]) != "" {
+			direction = strings.TrimSpace(record[3])
+		}
+
+		if direction != PlayForward && direction != PlayBackward && direction != PlayPingPong {
+			return fmt.Errorf("tag record %d: %w: unknown direction %q", i+1, ErrInvalidTagRecord, direction)
+		}
+
+		tags = append(tags, Tag{
+			Name:      strings.TrimSpace(record[0]),
+			Start:     start,
+			End:       end,
+			Direction: direction,
+			File:      file,
+		})
+
+	}
+
+	for _, tag := range tags {
+
+		replaced := false
+
+		for i := range file.Tags {
+			if file.Tags[i].Name == tag.Name {
+				file.Tags[i] = tag
+				replaced = true
+			}
+		}
+
+		if !replaced {
+			file.Tags = append(file.Tags, tag)
+		}
+
+	}
+
+	return nil
+
+}
+
+// Manifest is a compact, serializable description of a File's animations, as returned by File.Manifest(). It can be marshaled
+// with encoding/json, for sending animation definitions to a client or saving them alongside other assets.
+type Manifest struct {
+	Width          int32         `json:"width"`          // The width of the spritesheet image.
+	Height         int32         `json:"height"`         // The height of the spritesheet image.
+	FrameWidth     int32         `json:"frameWidth"`     // The width of the frames (the canvas width in Aseprite).
+	FrameHeight    int32         `json:"frameHeight"`    // The height of the frames (the canvas height in Aseprite).
+	FrameDurations []float32     `json:"frameDurations"` // The duration of each frame, in order.
+	Tags           []ManifestTag `json:"tags"`           // The File's Tags, in order.
+	Slices         []string      `json:"slices"`         // The names of the File's Slices, in order.
+}
+
+// ManifestTag is the description of a Tag within a Manifest.
+type ManifestTag struct {
+	Name      string `json:"name"`
+	Start     int    `json:"start"`
+	End       int    `json:"end"`
+	Direction string `json:"direction"`
+}
+
+// Manifest returns a compact, serializable description of the File's dimensions, frame durations, Tags, and Slice names,
+// independent of the more verbose Aseprite JSON format.
+func (file *File) Manifest() Manifest {
+
+	manifest := Manifest{
+		Width:          file.Width,
+		Height:         file.Height,
+		FrameWidth:     file.FrameWidth,
+		FrameHeight:    file.FrameHeight,
+		FrameDurations: make([]float32, 0, len(file.Frames)),
+		Tags:           make([]ManifestTag, 0, len(file.Tags)),
+		Slices:         make([]string, 0, len(file.Slices)),
+	}
+
+	for _, frame := range file.Frames {
+		manifest.FrameDurations = append(manifest.FrameDurations, frame.Duration)
+	}
+
+	for _, tag := range file.Tags {
+		manifest.Tags = append(manifest.Tags, ManifestTag{
+			Name:      tag.Name,
+			Start:     tag.Start,
+			End:       tag.End,
+			Direction: tag.Direction,
+		})
+	}
+
+	for _, slice := range file.Slices {
+		manifest.Slices = append(manifest.Slices, slice.Name)
+	}
+
+	return manifest
+
+}
+
+// CreatePlayer returns a new animation player that plays animations from a given Aseprite file.
+func (file *File) CreatePlayer() *Player {
+	return &Player{
+		File:      file,
+		PlaySpeed: 1,
+	}
+}
+
+// CreatePlayerPlaying returns a new animation player that plays animations from a given Aseprite file, and that's already
+// playing the tag of the given name (or the entire file, if tagName is ""). If the File has no tag by that name, the Player
+// is returned without playing anything.
+func (file *File) CreatePlayerPlaying(tagName string) *Player {
+	player := file.CreatePlayer()
+	player.Play(tagName)
+	return player
+}
+
+// Clone clones the Player.
+func (player *Player) Clone() *Player {
+	newPlayer := player.File.CreatePlayer()
+	newPlayer.PlaySpeed = player.PlaySpeed
+	newPlayer.CurrentTag = player.CurrentTag
+	newPlayer.FrameIndex = player.FrameIndex
+	newPlayer.Loops = player.Loops
+	newPlayer.EndHold = player.EndHold
+	newPlayer.FallbackToDefaultTag = player.FallbackToDefaultTag
+	newPlayer.FallbackTag = player.FallbackTag
+	newPlayer.ClampFrameToTag = player.ClampFrameToTag
+	newPlayer.SkipEmptyFrames = player.SkipEmptyFrames
+	newPlayer.img = player.img
+	newPlayer.AutoPauseOnFinish = player.AutoPauseOnFinish
+	newPlayer.frameCounter = player.frameCounter
+	newPlayer.playDirection = player.playDirection
+	newPlayer.tagDirection = player.tagDirection
+	newPlayer.playOptions = player.playOptions
+	newPlayer.loopCount = player.loopCount
+	newPlayer.totalFramesPlayed = player.totalFramesPlayed
+	newPlayer.holding = player.holding
+	newPlayer.finished = player.finished
+	newPlayer.paused = player.paused
+	newPlayer.autoPaused = player.autoPaused
+	newPlayer.easing = player.easing
+	newPlayer.easeTime = player.easeTime
+	newPlayer.easeDuration = player.easeDuration
+	newPlayer.easeSpeed = player.easeSpeed
+	newPlayer.FlipH = player.FlipH
+	newPlayer.FlipV = player.FlipV
+	newPlayer.TintColor = player.TintColor
+
+	newPlayer.OnLoop = player.OnLoop
+	newPlayer.OnFrameChange = player.OnFrameChange
+	newPlayer.OnFrameChangeTimed = player.OnFrameChangeTimed
+	newPlayer.OnDirectionChange = player.OnDirectionChange
+	newPlayer.OnTagEnter = player.OnTagEnter
+	newPlayer.OnTagExit = player.OnTagExit
+	newPlayer.OnFinish = player.OnFinish
+	newPlayer.OnUpdate = player.OnUpdate
+
+	for frameIndex, callbacks := range player.reachFrameCallbacks {
+		for _, fn := range callbacks {
+			newPlayer.OnReachFrame(frameIndex, fn)
+		}
+	}
+
+	for name, mark := range player.bookmarks {
+		if newPlayer.bookmarks == nil {
+			newPlayer.bookmarks = map[string]bookmark{}
+		}
+		newPlayer.bookmarks[name] = mark
+	}
+
+	if player.sequence != nil {
+		newPlayer.sequence = append([]Tag{}, player.sequence...)
+		newPlayer.sequenceIndex = player.sequenceIndex
+	}
+
+	if player.crossfade != nil {
+		newPlayer.crossfade = player.crossfade.Clone()
+		newPlayer.crossfadeTime = player.crossfadeTime
+		newPlayer.crossfadeDuration = player.crossfadeDuration
+	}
+
+	return newPlayer
+}
+
+// EventChannel returns a channel that receives an Event whenever the Player changes frames, enters or exits a tag, or loops,
+// for use in event-driven code (e.g. in a select loop) instead of, or alongside, the callback fields. The channel is created
+// on the first call and buffers up to EventBufferSize Events; if it's full when an Event happens, that Event is dropped
+// rather than blocking playback, so drain it regularly.
+func (player *Player) EventChannel() <-chan Event {
+	if player.events == nil {
+		player.events = make(chan Event, EventBufferSize)
+	}
+	return player.events
+}
+
+// emit sends an Event of the given type to the Player's event channel, if it has one, dropping the Event if the channel is full.
+func (player *Player) emit(eventType EventType, tag Tag) {
+
+	if player.events == nil {
+		return
+	}
+
+	select {
+	case player.events <- Event{Type: eventType, FrameIndex: player.FrameIndex, Tag: tag}:
+	default:
+	}
+
+}
+
+// OnReachFrame registers a callback that gets called whenever playback arrives at the frame with the given absolute index
+// (i.e. the index in File.Frames, not in the currently playing tag) during Update(). Multiple callbacks can be registered
+// for the same frame; they're called in the order they were registered.
+func (player *Player) OnReachFrame(absoluteIndex int, fn func()) {
+	if player.reachFrameCallbacks == nil {
+		player.reachFrameCallbacks = map[int][]func(){}
+	}
+	player.reachFrameCallbacks[absoluteIndex] = append(player.reachFrameCallbacks[absoluteIndex], fn)
+}
+
+// ClearReachFrame removes all callbacks registered through OnReachFrame() for the frame with the given absolute index.
+func (player *Player) ClearReachFrame(absoluteIndex int) {
+	delete(player.reachFrameCallbacks, absoluteIndex)
+}
+
+// ClearAllReachFrames removes all callbacks registered through OnReachFrame().
+func (player *Player) ClearAllReachFrames() {
+	player.reachFrameCallbacks = nil
+}
+
+// Play sets the specified tag name up to be played back. A tagName of "" will play back the entire file. Playing the tag that's
+// already playing does nothing, unless that tag has finished (in which case it restarts).
+// If the File has no tag by the given name, Play returns ErrNoTagByName; if the Player's FallbackTag is set (and exists), that
+// tag is played instead. Otherwise, if FallbackToDefaultTag is true, the default ("") tag is played instead, while if it's false
+// (the default), the current tag keeps playing.
+func (player *Player) Play(tagName string) error {
+
+	exists := false
+
+	for _, anim := range player.File.Tags {
+
+		if anim.Name == tagName {
+
+			exists = true
+
+			player.crossfade = nil
+			player.sequence = nil
+
+			if !anim.equals(player.CurrentTag) || player.finished {
+				player.startTag(anim, anim.Direction, firstFrame(anim, anim.Direction))
+			}
+
+			break
+
+		}
+	}
+
+	if !exists {
+
+		if player.FallbackTag != "" && player.FallbackTag != tagName && player.File.HasTag(player.FallbackTag) {
+			player.Play(player.FallbackTag)
+		} else if player.FallbackToDefaultTag && tagName != "" {
+			player.Play("")
+		}
+
+		return ErrNoTagByName
+
+	}
+
+	return nil
+
+}
+
+// FitToDuration sets the Player's PlaySpeed so that a single loop through the currently playing tag (going forward and back
+// for ping-pong tags) takes the given number of seconds. A value of 0 or less reverts the PlaySpeed to 1. If no tag is
+// playing, or the tag's frames all last no time, FitToDuration does nothing.
+func (player *Player) FitToDuration(seconds float32) {
+	player.PlaySpeed = player.RealtimeSpeed(seconds)
+}
+
+// RealtimeSpeed returns the PlaySpeed needed for a single loop through the currently playing tag to take targetDuration
+// seconds of wall-clock time, without changing the Player's PlaySpeed. Since Update() advances by real elapsed time, the
+// result holds regardless of the frame rate the game renders at. A targetDuration of 0 or less returns 1. If no tag is
+// playing, or no PlaySpeed could fit the tag to targetDuration (as when all of its frames last no time), the Player's
+// current PlaySpeed is returned.
+func (player *Player) RealtimeSpeed(targetDuration float32) float32 {
+
+	if targetDuration <= 0 {
+		return 1
+	}
+
+	if player.CurrentTag.IsEmpty() {
+		return player.PlaySpeed
+	}
+
+	loopDuration, tagSpeed := player.CurrentTag.loopDuration(player.tagDirection), player.tagSpeed()
+
+	if loopDuration <= 0 || tagSpeed <= 0 {
+		return player.PlaySpeed
+	}
+
+	return loopDuration / (targetDuration * tagSpeed)
+
+}
+
+// PlayFromFrame plays the tag of the given name, starting from the frame at the given index within the tag (so an inTagIndex
+// of 0 is the tag's Start frame). Unlike Play(), the tag restarts from the given frame even if it's already playing. If the
+// File has no tag by the given name, ErrNoTagByName is returned; if the index is outside of the tag, ErrFrameOutOfRange is
+// returned. In either case, the current tag keeps playing.
+func (player *Player) PlayFromFrame(tagName string, inTagIndex int) error {
+
+	tag, exists := player.File.TagByName(tagName)
+
+	if !exists {
+		return ErrNoTagByName
+	}
+
+	if inTagIndex < 0 || tag.Start+inTagIndex > tag.End {
+		return ErrFrameOutOfRange
+	}
+
+	player.crossfade = nil
+	player.sequence = nil
+	player.startTag(tag, tag.Direction, tag.Start+inTagIndex)
+
+	return nil
+
+}
+
+// PlayOptions holds options for playing a tag using Player.PlayEx().
+type PlayOptions struct {
+	Direction  string  // The direction to play the tag in (one of the playback constants); if blank, the tag's own Direction is used.
+	Loops      int     // The number of times to play the tag before finishing; 0 loops indefinitely, and 1 plays the tag once. Overrides Player.Loops for this playthrough only.
+	Speed      float32 // The playback speed multiplier for this playthrough, applied on top of the Player's PlaySpeed; 0 or less plays at normal speed.
+	StartFrame int     // The index of the frame to start on, counting in play order (so 0 is the tag's End frame when playing in reverse).
+}
+
+// PlayEx plays the tag of the given name using the given PlayOptions. Unlike Play(), the tag restarts even if it's already
+// playing. The options only apply until another tag starts playing; the Player's own Loops and PlaySpeed are left untouched.
+// If the File has no tag by the given name, ErrNoTagByName is returned; if the StartFrame is outside of the tag,
+// ErrFrameOutOfRange is returned. In either case, the current tag keeps playing.
+func (player *Player) PlayEx(tagName string, opts PlayOptions) error {
+
+	tag, exists := player.File.TagByName(tagName)
+
+	if !exists {
+		return ErrNoTagByName
+	}
+
+	if opts.StartFrame < 0 || tag.Start+opts.StartFrame > tag.End {
+		return ErrFrameOutOfRange
+	}
+
+	direction := opts.Direction
+	if direction == "" {
+		direction = tag.Direction
+	}
+
+	frameIndex := firstFrame(tag, direction) + opts.StartFrame
+	if direction == PlayBackward {
+		frameIndex = firstFrame(tag, direction) - opts.StartFrame
+	}
+
+	player.crossfade = nil
+	player.sequence = nil
+	player.startTag(tag, direction, frameIndex)
+
+	player.playOptions = &opts
+
+	return nil
+
+}
+
+// loops returns the number of times the current tag plays before finishing; this is the Loops given to PlayEx() if the tag was
+// played with it, or the Player's Loops otherwise.
+func (player *Player) loops() int {
+	if player.playOptions != nil {
+		return player.playOptions.Loops
+	}
+	return player.Loops
+}
+
+// tagSpeed returns the playback speed multiplier of the current tag, combining the tag's own speed (see Tag.Meta) with the
+// Speed given to PlayEx(), if the tag was played with it. The Player's PlaySpeed isn't included.
+func (player *Player) tagSpeed() float32 {
+	speed := player.CurrentTag.speed()
+	if player.playOptions != nil && player.playOptions.Speed > 0 {
+		speed *= player.playOptions.Speed
+	}
+	return speed
+}
+
+// firstFrame returns the index of the first frame of the given tag when playing it in the given direction.
+func firstFrame(tag Tag, direction string) int {
+	if direction == PlayBackward {
+		return tag.End
+	}
+	return tag.Start
+}
+
+// PlayReversed plays the tag of the given name backward, starting from its End frame, regardless of the tag's own Direction.
+// Unlike Play(), the tag restarts even if it's already playing. If the File has no tag by the given name, ErrNoTagByName is
+// returned and the current tag keeps playing.
+func (player *Player) PlayReversed(tagName string) error {
+
+	tag, exists := player.File.TagByName(tagName)
+
+	if !exists {
+		return ErrNoTagByName
+	}
+
+	player.crossfade = nil
+	player.sequence = nil
+	player.startTag(tag, PlayBackward, firstFrame(tag, PlayBackward))
+
+	return nil
+
+}
+
+// PlaySubrange plays the tag of the given name, but only loops through the frames from the fromInTag index to the toInTag
+// index within the tag (inclusive, so 0 is the tag's Start frame), firing OnLoop each time playback wraps around the subrange.
+// While playing a subrange, the Player's CurrentTag has its Start and End narrowed to the subrange. Unlike Play(), the tag
+// restarts even if it's already playing. If the File has no tag by the given name, ErrNoTagByName is returned; if the
+// subrange is empty or extends outside of the tag, ErrFrameOutOfRange is returned. In either case, the current tag keeps playing.
+func (player *Player) PlaySubrange(tagName string, fromInTag, toInTag int) error {
+
+	tag, exists := player.File.TagByName(tagName)
+
+	if !exists {
+		return ErrNoTagByName
+	}
+
+	if fromInTag < 0 || fromInTag > toInTag || tag.Start+toInTag > tag.End {
+		return ErrFrameOutOfRange
+	}
+
+	tag.Start, tag.End = tag.Start+fromInTag, tag.Start+toInTag
+
+	player.crossfade = nil
+	player.sequence = nil
+	player.startTag(tag, tag.Direction, firstFrame(tag, tag.Direction))
+
+	return nil
+
+}
+
+// startTag starts playing the given tag in the given direction from the frame with the given index, calling OnTagEnter and
+// OnTagExit as necessary.
+func (player *Player) startTag(tag Tag, direction string, frameIndex int) {
+
+	if !player.CurrentTag.IsEmpty() {
+		player.PrevFrameIndex = -1
+	} else {
+		player.PrevFrameIndex = player.FrameIndex
+	}
+
+	if player.autoPaused {
+		player.paused = false
+		player.autoPaused = false
+	}
+
+	player.CurrentTag = tag
+	player.frameCounter = 0
+	player.loopCount = 0
+	player.playOptions = nil
+	player.holding = false
+	player.finished = false
+
+	player.tagDirection = direction
+
+	if direction == PlayBackward {
+		player.playDirection = -1
+	} else {
+		player.playDirection = 1
+	}
+
+	player.FrameIndex = frameIndex
+
+	player.pollTagChanges()
+
+}
+
+// PlaySequencePingPong plays the tags with the given names as a single ping-pong sequence: each tag is played forward in the
+// order given, and then each tag is played backward in reverse order, looping. OnTagEnter and OnTagExit are called as playback
+// crosses from one tag to the next, and OnLoop is called on each full forward and back cycle. Calling Play() stops the sequence.
+func (player *Player) PlaySequencePingPong(tagNames ...string) error {
+
+	sequence := []Tag{}
+
+	for _, tagName := range tagNames {
+		tag, exists := player.File.TagByName(tagName)
+		if !exists {
+			return ErrNoTagByName
+		}
+		sequence = append(sequence, tag)
+	}
+
+	if len(sequence) == 0 {
+		return ErrNoTagByName
+	}
+
+	if err := player.Play(sequence[0].Name); err != nil {
+		return err
+	}
+
+	player.sequence = sequence
+	player.sequenceIndex = 0
+	player.playDirection = 1
+	player.tagDirection = PlayForward
+	player.FrameIndex = sequence[0].Start
+	player.frameCounter = 0
+
+	return nil
+
+}
+
+// SetSeed seeds the random number generator used by the Player for random playback features (like PlayRandom()), so
+// that identical seeds produce identical sequences of random decisions.
+func (player *Player) SetSeed(seed int64) {
+	player.rng = rand.New(rand.NewSource(seed))
+}
+
+// SetRand sets the random number generator used by the Player for random playback features (like PlayRandom()). Passing
+// nil makes the Player create a time-seeded generator the next time it needs one.
+func (player *Player) SetRand(rng *rand.Rand) {
+	player.rng = rng
+}
+
+// random returns the Player's random number generator, creating a time-seeded one if none has been set.
+func (player *Player) random() *rand.Rand {
+	if player.rng == nil {
+		player.SetSeed(time.Now().UnixNano())
+	}
+	return player.rng
+}
+
+// PlayRandom plays a tag chosen at random from the given tag names. If no tag names are given, the tag is chosen from all of
+// the File's Tags. The choice is made using the Player's random number generator (see SetSeed() and SetRand()).
+func (player *Player) PlayRandom(tagNames ...string) error {
+
+	if len(tagNames) == 0 {
+		for _, tag := range player.File.Tags {
+			tagNames = append(tagNames, tag.Name)
+		}
+	}
+
+	if len(tagNames) == 0 {
+		return ErrNoTagByName
+	}
+
+	return player.Play(tagNames[player.random().Intn(len(tagNames))])
+
+}
+
+// CrossfadeTo starts a transition to the specified tag that lasts for the given duration in seconds. During the transition, the
+// Player keeps playing its current tag while the incoming tag plays alongside it; both frames can be retrieved using
+// TransitionFrames() so that they can be blended together when rendering. Once the transition is over, the Player snaps to the
+// incoming tag, continuing from where the incoming playback left off. A duration of 0 or less plays the tag immediately.
+func (player *Player) CrossfadeTo(tagName string, duration float32) error {
+
+	if duration <= 0 {
+		return player.Play(tagName)
+	}
+
+	incoming := player.File.CreatePlayer()
+	incoming.PlaySpeed = player.PlaySpeed
+
+	if err := incoming.Play(tagName); err != nil {
+		return err
+	}
+
+	player.crossfade = incoming
+	player.crossfadeTime = 0
+	player.crossfadeDuration = duration
+
+	return nil
+
+}
+
+// TransitionFrames returns the rectangles of the outgoing and incoming frames on the spritesheet while a transition started by
+// CrossfadeTo() is in progress, along with the progress of the transition (t, ranging from 0 to 1) and a boolean indicating
+// if a transition is active. If no transition is active, the rectangles are empty and t is 0.
+func (player *Player) TransitionFrames() (from image.Rectangle, to image.Rectangle, t float32, active bool) {
+
+	if player.crossfade == nil {
+		return image.Rectangle{}, image.Rectangle{}, 0, false
+	}
+
+	if !player.CurrentTag.IsEmpty() {
+		from = player.File.frameRect(player.FrameIndex)
+	}
+
+	to = player.File.frameRect(player.crossfade.FrameIndex)
+
+	return from, to, player.crossfadeTime / player.crossfadeDuration, true
+
+}
+
+// updateCrossfade advances the transition started by CrossfadeTo(), snapping to the incoming tag once the transition is over.
+func (player *Player) updateCrossfade(dt, playSpeed float32) {
+
+	if player.crossfade == nil {
+		return
+	}
+
+	incoming := player.crossfade
+	incoming.PlaySpeed = player.PlaySpeed
+	incoming.update(dt, playSpeed)
+
+	player.crossfadeTime += dt
+
+	if player.crossfadeTime >= player.crossfadeDuration {
+
+		player.crossfade = nil
+
+		player.Play(incoming.CurrentTag.Name)
+		player.FrameIndex = incoming.FrameIndex
+		player.frameCounter = incoming.frameCounter
+		player.playDirection = incoming.playDirection
+		player.tagDirection = incoming.tagDirection
+
+	}
+
+}
+
+// Bookmark saves the Player's current playback position (the playing tag, the current frame, and how far into it playback
+// is) under the given name, overwriting any bookmark that already has that name. Use GotoBookmark() to return to it later.
+func (player *Player) Bookmark(name string) {
+	if player.bookmarks == nil {
+		player.bookmarks = map[string]bookmark{}
+	}
+	player.bookmarks[name] = bookmark{
+		tag:           player.CurrentTag,
+		frameIndex:    player.FrameIndex,
+		frameCounter:  player.frameCounter,
+		playDirection: player.playDirection,
+		tagDirection:  player.tagDirection,
+	}
+}
+
+// GotoBookmark restores the playback position saved under the given name with Bookmark(), and returns if a bookmark by that
+// name exists. Restoring a bookmark restarts the tag's loop count, and stops any crossfade or sequence that's in progress.
+func (player *Player) GotoBookmark(name string) bool {
+
+	mark, exists := player.bookmarks[name]
+
+	if !exists {
+		return false
+	}
+
+	player.crossfade = nil
+	player.sequence = nil
+	player.startTag(mark.tag, mark.tagDirection, mark.frameIndex)
+	player.frameCounter = mark.frameCounter
+	player.playDirection = mark.playDirection
+
+	return true
+
+}
+
+// Pause pauses the Player, so that Update() doesn't advance playback until Resume() is called.
+func (player *Player) Pause() {
+	player.paused = true
+	player.autoPaused = false
+}
+
+// Resume resumes a Player paused with Pause().
+func (player *Player) Resume() {
+	player.paused = false
+	player.autoPaused = false
+}
+
+// IsPaused returns if the Player is paused.
+func (player *Player) IsPaused() bool {
+	return player.paused
+}
+
+// TogglePause pauses the Player if it's playing, or resumes it if it's paused.
+func (player *Player) TogglePause() {
+	player.paused = !player.paused
+	player.autoPaused = false
+}
+
+// SetImage associates the File's spritesheet image with the Player, for convenience methods that need the image itself (like
+// CurrentImage()) or its pixels (like SkipEmptyFrames). Setting the image is optional, so engine-specific image types can
+// still be used separately; pass nil to clear it.
+func (player *Player) SetImage(img image.Image) {
+	player.img = img
+	player.emptyFrames = nil
+}
+
+// Image returns the spritesheet image set with SetImage(), or nil if none has been set.
+func (player *Player) Image() image.Image {
+	return player.img
+}
+
+// CurrentImage returns the part of the image set with SetImage() that the current frame occupies. It returns nil if no image
+// has been set, if no tag is playing, or if the image doesn't support taking sub-images (as the standard library's image
+// types do, through a SubImage() method).
+func (player *Player) CurrentImage() image.Image {
+
+	sub, ok := player.img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	})
+
+	if !ok || player.CurrentTag.IsEmpty() {
+		return nil
+	}
+
+	return sub.SubImage(player.File.frameRect(player.FrameIndex))
+
+}
+
+// frameEmpty returns if the frame with the given index is fully transparent in the Player's image. Results are cached on the
+// Player rather than the File (as File.ContentBounds() does), as Players sharing a File can each have a different image.
+func (player *Player) frameEmpty(frameIndex int) bool {
+
+	if empty, cached := player.emptyFrames[frameIndex]; cached {
+		return empty
+	}
+
+	empty := contentBounds(player.img, player.File.frameRect(frameIndex)).Empty()
+
+	if player.emptyFrames == nil {
+		player.emptyFrames = map[int]bool{}
+	}
+	player.emptyFrames[frameIndex] = empty
+
+	return empty
+
+}
+
+// frameDuration returns the duration of the frame with the given index during playback. This is the frame's Duration, except
+// that fully transparent frames last no time at all when SkipEmptyFrames is set (as long as the playing tag has some frames
+// that aren't empty), so Update() passes over them instantly.
+func (player *Player) frameDuration(frameIndex int) float32 {
+
+	if player.skipsFrame(frameIndex) {
+		return 0
+	}
+
+	return player.File.Frames[frameIndex].Duration
+
+}
+
+// skipsFrame returns if playback passes over the frame with the given index because of SkipEmptyFrames; that is, if the
+// frame is fully transparent while the playing tag has some frames that aren't.
+func (player *Player) skipsFrame(frameIndex int) bool {
+
+	if player.SkipEmptyFrames && player.img != nil && player.frameEmpty(frameIndex) {
+		for i := player.CurrentTag.Start; i <= player.CurrentTag.End; i++ {
+			if !player.frameEmpty(i) {
+				return true
+			}
+		}
+	}
+
+	return false
+
+}
+
+// EffectiveSpeed returns the speed multiplier that Update() currently applies to playback: the PlaySpeed (including any
+// slowdown from EaseStop()) multiplied by the playing tag's own speed from its user data. It returns 0 if the Player is
+// paused or isn't playing a tag.
+func (player *Player) EffectiveSpeed() float32 {
+
+	if player.paused || player.CurrentTag.IsEmpty() {
+		return 0
+	}
+
+	return player.PlaySpeed * player.tagSpeed()
+
+}
+
+// EaseStop smoothly slows the Player's PlaySpeed down to 0 over the given number of seconds, and then pauses the Player,
+// holding the current frame (for hit-stop or freeze effects, for example). Once the Player is paused, its PlaySpeed is
+// restored to what it was when EaseStop() was called, so calling Resume() continues playback at the original speed. A
+// duration of 0 or less pauses the Player immediately.
+func (player *Player) EaseStop(duration float32) {
+
+	if player.easing {
+		player.PlaySpeed = player.easeSpeed
+	}
+
+	if duration <= 0 {
+		player.easing = false
+		player.paused = true
+		return
+	}
+
+	player.easing = true
+	player.easeTime = 0
+	player.easeDuration = duration
+	player.easeSpeed = player.PlaySpeed
+
+}
+
+// IsEasing returns if the Player is slowing down to a stop because of EaseStop().
+func (player *Player) IsEasing() bool {
+	return player.easing
+}
+
+// updateEase advances the slowdown started by EaseStop(), pausing the Player once it's over.
+func (player *Player) updateEase(dt float32) {
+
+	if !player.easing || player.paused {
+		return
+	}
+
+	player.easeTime += dt
+
+	if player.easeTime >= player.easeDuration {
+		player.easing = false
+		player.paused = true
+		player.PlaySpeed = player.easeSpeed
+		return
+	}
+
+	player.PlaySpeed = player.easeSpeed * (1 - player.easeTime/player.easeDuration)
+
+}
+
+// Update updates the currently playing animation. dt is the delta value between the previous frame and the current frame.
+// Update does nothing while the Player is paused.
+func (player *Player) Update(dt float32) {
+	player.updateEase(dt)
+	player.update(dt, player.PlaySpeed)
+	if player.OnUpdate != nil {
+		player.OnUpdate(dt)
+	}
+}
+
+// AdvanceRealtime advances playback by the given number of seconds, ignoring the Player's PlaySpeed (though any speed
+// set in the playing tag's user data still applies). Otherwise, it behaves just like Update(), which makes it useful for
+// stepping through animations deterministically in tools and tests. Any slowdown started with EaseStop() still advances.
+func (player *Player) AdvanceRealtime(seconds float32) {
+	player.updateEase(seconds)
+	player.update(seconds, 1)
+	if player.OnUpdate != nil {
+		player.OnUpdate(seconds)
+	}
+}
+
+// update advances playback by dt seconds at the given play speed.
+func (player *Player) update(dt, playSpeed float32) {
+
+	player.justLooped = false
+	player.justChangedFrame = false
+	player.framesCrossed = player.framesCrossed[:0]
+
+	if player.paused {
+		return
+	}
+
+	anim := player.CurrentTag
+
+	if player.ClampFrameToTag && !anim.IsEmpty() {
+		if player.FrameIndex < anim.Start {
+			player.FrameIndex = anim.Start
+		} else if player.FrameIndex > anim.End {
+			player.FrameIndex = anim.End
+		}
+	}
+
+	if !anim.IsEmpty() && !player.finished {
+
+		speed := playSpeed * player.tagSpeed()
+
+		player.frameCounter += dt * speed
+
+		if player.holding {
+			player.updateHold()
+		}
+
+		// The last frame actually shown; frames skipped because of SkipEmptyFrames are passed over without any frame events.
+		shownFrame := player.FrameIndex
+
+		// The duration is looked up for each frame as it's reached, so that leftover time carried over from one frame is measured
+		// against the duration of the frame that follows it (including after wrapping or bouncing at a tag's bounds).
+		for !player.holding && player.frameCounter >= player.frameDuration(player.FrameIndex) {
+
+			player.frameCounter -= player.frameDuration(player.FrameIndex)
+
+			step := player.nextStep()
+
+			if step.looped && player.loops() > 0 && player.loopCount+1 >= player.loops() {
+				player.holding = true
+				player.updateHold()
+				break
+			}
+
+			prevDirection := player.playDirection
+
+			player.applyStep(step)
+
+			skipped := player.skipsFrame(player.FrameIndex)
+			if !skipped {
+				player.PrevFrameIndex = shownFrame
+				shownFrame = player.FrameIndex
+			}
+
+			if step.looped {
+				player.justLooped = true
+				player.loopCount++
+				if player.OnLoop != nil {
+					player.OnLoop()
+				}
+				player.emit(EventLoop, player.CurrentTag)
+			}
+
+			if player.playDirection != prevDirection && player.OnDirectionChange != nil {
+				player.OnDirectionChange(player.playDirection)
+			}
+
+			if skipped {
+				continue
+			}
+
+			if player.FrameIndex != player.PrevFrameIndex {
+				player.justChangedFrame = true
+				player.totalFramesPlayed++
+			}
+
+			player.framesCrossed = append(player.framesCrossed, player.FrameIndex)
+
+			if player.FrameIndex != player.PrevFrameIndex {
+				if player.OnFrameChange != nil {
+					player.OnFrameChange()
+				}
+				player.emit(EventFrameChange, player.CurrentTag)
+			}
+
+			if player.FrameIndex != player.PrevFrameIndex && player.OnFrameChangeTimed != nil {
+				// Whatever's left in the frame counter is how far past the frame boundary playback is at the end of the tick.
+				timeIntoTick := dt
+				if speed > 0 {
+					timeIntoTick -= player.frameCounter / speed
+				}
+				if timeIntoTick < 0 {
+					timeIntoTick = 0
+				}
+				player.OnFrameChangeTimed(player.FrameIndex, timeIntoTick)
+			}
+
+			for _, fn := range player.reachFrameCallbacks[player.FrameIndex] {
+				fn()
+			}
+
+			player.pollTagChanges()
+
+		}
+
+	}
+
+	player.updateCrossfade(dt, playSpeed)
+
+}
+
+// JustLooped returns if the playing tag looped during the most recent Update() call.
+func (player *Player) JustLooped() bool {
+	return player.justLooped
+}
+
+// JustChangedFrame returns if the current frame changed during the most recent Update() call.
+func (player *Player) JustChangedFrame() bool {
+	return player.justChangedFrame
+}
+
+// TotalFramesPlayed returns the number of times Update() has changed the Player's current frame since the Player was created
+// (or since ResetCounters() was last called). Playing a new tag doesn't reset the count.
+func (player *Player) TotalFramesPlayed() int {
+	return player.totalFramesPlayed
+}
+
+// ResetCounters resets the count returned by TotalFramesPlayed() to 0.
+func (player *Player) ResetCounters() {
+	player.totalFramesPlayed = 0
+}
+
+// FramesCrossedLastUpdate returns the indices of the frames that playback advanced to during the most recent Update() call,
+// in the order they were reached. With a high PlaySpeed or a large dt, this can include several frames (and the same frame
+// more than once, if the tag looped).
+func (player *Player) FramesCrossedLastUpdate() []int {
+	return append([]int(nil), player.framesCrossed...)
+}
+
+// updateHold finishes the playing tag once its last frame has been held for EndHold seconds.
+func (player *Player) updateHold() {
+
+	if player.frameCounter >= player.EndHold {
+
+		player.holding = false
+		player.finished = true
+		player.frameCounter = 0
+
+		player.finish()
+
+	}
+
+}
+
+// SetOnFinishOnce sets a callback that gets called the next time the playing tag finishes (after OnFinish), and is then
+// cleared, which is handy for one-off animations played with a set number of Loops. Setting another callback replaces the
+// previous one, and setting nil clears it. The callback isn't copied by Clone().
+func (player *Player) SetOnFinishOnce(fn func()) {
+	player.onFinishOnce = fn
+}
+
+// finish is called when the playing tag finishes; it pauses the Player if AutoPauseOnFinish is set, and calls OnFinish and
+// the callback set with SetOnFinishOnce(), clearing the latter.
+func (player *Player) finish() {
+
+	if player.AutoPauseOnFinish {
+		player.paused = true
+		player.autoPaused = true
+	}
+
+	if player.OnFinish != nil {
+		player.OnFinish()
+	}
+
+	if fn := player.onFinishOnce; fn != nil {
+		player.onFinishOnce = nil
+		fn()
+	}
+
+}
+
+// JumpToTagEnd sets the current frame to the last frame of the currently playing tag in play order (the End frame for forward
+// tags, and the Start frame for reverse and ping-pong tags) and finishes the tag, stopping playback. OnFrameChange, OnTagEnter,
+// OnTagExit, and OnFinish are called as appropriate.
+func (player *Player) JumpToTagEnd() {
+
+	tag := player.CurrentTag
+
+	if tag.IsEmpty() {
+		return
+	}
+
+	player.PrevFrameIndex = player.FrameIndex
+
+	if player.tagDirection == PlayBackward || player.tagDirection == PlayPingPong {
+		player.FrameIndex = tag.Start
+	} else {
+		player.FrameIndex = tag.End
+	}
+
+	player.frameCounter = 0
+	player.holding = false
+	player.finished = true
+
+	if player.FrameIndex != player.PrevFrameIndex {
+		if player.OnFrameChange != nil {
+			player.OnFrameChange()
+		}
+		player.emit(EventFrameChange, player.CurrentTag)
+	}
+
+	player.pollTagChanges()
+
+	player.finish()
+
+}
+
+// RemainingTime returns the time, in seconds, left until the current pass through the playing tag completes; this is when the
+// tag next loops, or when it finishes (including EndHold) if it's on its last loop. The time accounts for the PlaySpeed and the
+// play direction. If no tag is playing, RemainingTime returns -1.
+func (player *Player) RemainingTime() float32 {
+
+	if player.CurrentTag.IsEmpty() {
+		return -1
+	}
+
+	if player.finished {
+		return 0
+	}
+
+	lastLoop := player.loops() > 0 && player.loopCount+1 >= player.loops()
+
+	remaining := float32(0)
+
+	if player.holding {
+		remaining = player.EndHold - player.frameCounter
+	} else {
+
+		sim := *player
+		remaining = player.frameDuration(sim.FrameIndex) - sim.frameCounter
+
+		for step := sim.nextStep(); !step.looped; step = sim.nextStep() {
+			sim.applyStep(step)
+			remaining += player.frameDuration(sim.FrameIndex)
+		}
+
+		if lastLoop {
+			remaining += player.EndHold
+		}
+
+	}
+
+	if speed := player.PlaySpeed * player.tagSpeed(); speed > 0 {
+		remaining /= speed
+	}
+
+	return remaining
+
+}
+
+// Finished returns if the playing tag has finished, which happens once it has looped Loops times and its last frame has
+// been held for EndHold seconds, or when JumpToTagEnd() is called.
+func (player *Player) Finished() bool {
+	return player.finished
+}
+
+// IsLooping returns if the current pass through the playing tag will loop back around once it's over; this is always true
+// when Loops is 0, and false during the last of a set number of Loops. IsLooping returns false if no tag is playing, or
+// if the tag has finished.
+func (player *Player) IsLooping() bool {
+
+	if player.CurrentTag.IsEmpty() || player.finished || player.holding {
+		return false
+	}
+
+	return player.loops() <= 0 || player.loopCount+1 < player.loops()
+
+}
+
+// frameStep describes where playback goes when advancing by a single frame.
+type frameStep struct {
+	frameIndex, direction, sequenceIndex int
+	looped                               bool // Whether the step completes a loop.
+}
+
+// nextStep returns the frameStep that follows the Player's current position, whether it's playing a tag or a sequence of tags.
+func (player *Player) nextStep() frameStep {
+
+	if player.sequence != nil {
+		return player.nextSequenceStep()
+	}
+
+	next, direction, looped := player.nextFrame(player.FrameIndex, player.playDirection)
+
+	return frameStep{frameIndex: next, direction: direction, looped: looped}
+
+}
+
+// applyStep moves the Player's playback to the position described by the given frameStep.
+func (player *Player) applyStep(step frameStep) {
+
+	player.PrevFrameIndex = player.FrameIndex
+	player.FrameIndex = step.frameIndex
+	player.playDirection = step.direction
+
+	if player.sequence != nil {
+		player.sequenceIndex = step.sequenceIndex
+		player.CurrentTag = player.sequence[step.sequenceIndex]
+		// Tags in a sequence are always played forward on the way out, whatever their own Direction is.
+		player.tagDirection = PlayForward
+	}
+
+}
+
+// nextSequenceStep returns the frameStep that follows the Player's current position in a sequence started with
+// PlaySequencePingPong(), moving on to the next (or previous) tag in the sequence when leaving the current one, and bouncing
+// at either end of the sequence.
+func (player *Player) nextSequenceStep() frameStep {
+
+	step := frameStep{
+		frameIndex:    player.FrameIndex + player.playDirection,
+		direction:     player.playDirection,
+		sequenceIndex: player.sequenceIndex,
+	}
+
+	tag := player.sequence[step.sequenceIndex]
+
+	if step.frameIndex > tag.End {
+
+		if step.sequenceIndex < len(player.sequence)-1 {
+			step.sequenceIndex++
+			step.frameIndex = player.sequence[step.sequenceIndex].Start
+		} else {
+			step.direction = -1
+			step.frameIndex = tag.End - 1
+			if step.frameIndex < tag.Start && step.sequenceIndex > 0 {
+				step.sequenceIndex--
+				step.frameIndex = player.sequence[step.sequenceIndex].End
+			} else if step.frameIndex < tag.Start {
+				step.frameIndex = tag.Start
+			}
+		}
+
+	} else if step.frameIndex < tag.Start {
+
+		if step.sequenceIndex > 0 {
+			step.sequenceIndex--
+			step.frameIndex = player.sequence[step.sequenceIndex].End
+		} else {
+			step.direction = 1
+			step.looped = true
+			step.frameIndex = tag.Start + 1
+			if step.frameIndex > tag.End && len(player.sequence) > 1 {
+				step.sequenceIndex++
+				step.frameIndex = player.sequence[step.sequenceIndex].Start
+			} else if step.frameIndex > tag.End {
+				step.frameIndex = tag.End
+			}
+		}
+
+	}
+
+	return step
+
+}
+
+// nextFrame returns the frame index and play direction that follow the given frame index and play direction in the currently
+// playing tag, wrapping around (or bouncing, for ping-pong tags) at the tag's bounds, along with whether doing so completes a loop.
+func (player *Player) nextFrame(frameIndex, direction int) (int, int, bool) {
+
+	anim := player.CurrentTag
+
+	frameIndex += direction
+
+	if player.tagDirection == PlayPingPong {
+
+		if frameIndex > anim.End {
+			return anim.End - 1, -direction, false
+		} else if frameIndex < anim.Start {
+			return anim.Start + 1, -direction, true
+		}
+
+	} else if direction > 0 && frameIndex > anim.End {
+		return frameIndex - (anim.End - anim.Start + 1), direction, true
+	} else if direction < 0 && frameIndex < anim.Start {
+		return frameIndex + (anim.End - anim.Start + 1), direction, true
+	}
+
+	return frameIndex, direction, false
+
+}
+
+// TagBoundaryRects returns the rectangles on the spritesheet of the first and last frames of the playing tag in play order
+// (so for a reverse tag, first is the End frame), and if a tag is playing. As with JumpToTagEnd(), the last frame of a
+// ping-pong tag is its Start frame. This is useful for lining up the pose an outgoing animation ends on with the pose an
+// incoming one starts on.
+func (player *Player) TagBoundaryRects() (first, last image.Rectangle, ok bool) {
+
+	tag := player.CurrentTag
+
+	if tag.IsEmpty() {
+		return image.Rectangle{}, image.Rectangle{}, false
+	}
+
+	lastFrame := tag.End
+	if player.tagDirection == PlayBackward || player.tagDirection == PlayPingPong {
+		lastFrame = tag.Start
+	}
+
+	return player.File.frameRect(firstFrame(tag, player.tagDirection)), player.File.frameRect(lastFrame), true
+
+}
+
+// NextFrameRect returns the rectangle on the spritesheet of the frame that will be shown after the current one, given the
+// current play direction and the bounds of the currently playing tag. If no tag is playing, it returns an empty rectangle; if
+// playback is stopped (the Player is paused, holding its last frame, or finished), it returns the current frame's rectangle.
+func (player *Player) NextFrameRect() image.Rectangle {
+
+	if player.CurrentTag.IsEmpty() {
+		return image.Rectangle{}
+	}
+
+	if player.paused || player.holding || player.finished {
+		return player.File.frameRect(player.FrameIndex)
+	}
+
+	return player.File.frameRect(player.nextStep().frameIndex)
+
+}
+
+// TouchingTags returns the tags currently being touched by the Player (tag).
+func (player *Player) TouchingTags() []Tag {
+	tags := []Tag{}
+	for _, t := range player.File.Tags {
+		if player.FrameIndex >= t.Start && player.FrameIndex <= t.End {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// TouchingTagByName returns if a tag by the given name is being touched by the Player (tag).
+func (player *Player) TouchingTagByName(tagName string) bool {
+	for _, t := range player.File.Tags {
+		if t.Name == tagName && player.FrameIndex >= t.Start && player.FrameIndex <= t.End {
+			return true
+		}
+	}
+	return false
+}
+
+// pollTagChanges polls the File for tag changes (entering or exiting Tags).
+func (player *Player) pollTagChanges() {
+
+	if player.OnTagExit != nil || player.events != nil {
+		for _, tag := range player.File.Tags {
+			if (player.PrevFrameIndex >= tag.Start && player.PrevFrameIndex <= tag.End) && (player.FrameIndex < tag.Start || player.FrameIndex > tag.End) {
+				if player.OnTagExit != nil {
+					player.OnTagExit(tag)
+				}
+				player.emit(EventTagExit, tag)
+			}
+		}
+	}
+
+	if player.OnTagEnter != nil || player.events != nil {
+		for _, tag := range player.File.Tags {
+			if (player.PrevFrameIndex < tag.Start || player.PrevFrameIndex > tag.End) && (player.FrameIndex >= tag.Start && player.FrameIndex <= tag.End) {
+				if player.OnTagEnter != nil {
+					player.OnTagEnter(tag)
+				}
+				player.emit(EventTagEnter, tag)
+			}
+		}
+	}
+
+}
+
+// CurrentFrame returns the current frame for the currently playing Tag in the File and a boolean indicating if the Player is playing a Tag or not.
+func (player *Player) CurrentFrame() (Frame, bool) {
+	if !player.CurrentTag.IsEmpty() {
+		return player.File.Frames[player.FrameIndex], true
+	}
+	return Frame{}, false
+}
+
+// CurrentFrameMotion returns the motion offset (OffsetX and OffsetY) of the current frame, for driving a character's
+// position from root motion authored alongside the animation. If the Player isn't playing a Tag, 0, 0 is returned.
+func (player *Player) CurrentFrameMotion() (int, int) {
+	if frame, ok := player.CurrentFrame(); ok {
+		return frame.OffsetX, frame.OffsetY
+	}
+	return 0, 0
+}
+
+// CurrentFrameDuration returns the duration, in seconds, of the current frame and a boolean indicating if the Player is
+// playing a Tag or not.
+func (player *Player) CurrentFrameDuration() (float32, bool) {
+	if frame, ok := player.CurrentFrame(); ok {
+		return frame.Duration, true
+	}
+	return 0, false
+}
+
+// CurrentSliceKeys returns the active SliceKey (see Slice.KeyAt()) of each of the File's Slices on the current frame, mapped
+// by the Slices' names. Slices that have no active key on the current frame are omitted. If multiple Slices share a name,
+// the first one with an active key is used. If no tag is playing, the map is empty. The keys are mirrored within the frame
+// if FlipH or FlipV are set.
+func (player *Player) CurrentSliceKeys() map[string]SliceKey {
+
+	keys := map[string]SliceKey{}
+
+	if player.CurrentTag.IsEmpty() {
+		return keys
+	}
+
+	for _, slice := range player.File.Slices {
+
+		if _, exists := keys[slice.Name]; exists {
+			continue
+		}
+
+		if key, ok := slice.KeyAt(player.FrameIndex); ok {
+			keys[slice.Name] = key.mirrored(int(player.File.FrameWidth), int(player.File.FrameHeight), player.FlipH, player.FlipV)
+		}
+
+	}
+
+	return keys
+
+}
+
+// CurrentFrameCoords returns the four corners of the current frame, of format (x1, y1, x2, y2). For trimmed frames, this is
+// the frame's trimmed rectangle on the spritesheet. If File.CurrentFrame() is nil, it will instead return all -1's.
+func (player *Player) CurrentFrameCoords() (int, int, int, int) {
+
+	if !player.CurrentTag.IsEmpty() {
+		rect := player.File.frameRect(player.FrameIndex)
+		return rect.Min.X, rect.Min.Y, rect.Max.X, rect.Max.Y
+	}
+
+	return -1, -1, -1, -1
+
+}
+
+// CurrentUVRectFlipped returns the current frame's rectangle on the spritesheet in UV space (0 to 1), of format (u0, v0, u1, v1),
+// where (u0, v0) is normally the top-left corner and (u1, v1) the bottom-right one. If flipH is true, u0 and u1 are swapped,
+// and if flipV is true, v0 and v1 are swapped, so the UVs can be used to draw a mirrored sprite directly. If File.CurrentFrame()
+// is nil, it will instead return all -1's.
+func (player *Player) CurrentUVRectFlipped(flipH, flipV bool) (u0, v0, u1, v1 float64) {
+
+	if player.CurrentTag.IsEmpty() {
+		return -1, -1, -1, -1
+	}
+
+	rect := player.File.frameRect(player.FrameIndex)
+	w, h := float64(player.File.Width), float64(player.File.Height)
+
+	u0, v0 = float64(rect.Min.X)/w, float64(rect.Min.Y)/h
+	u1, v1 = float64(rect.Max.X)/w, float64(rect.Max.Y)/h
+
+	if flipH {
+		u0, u1 = u1, u0
+	}
+
+	if flipV {
+		v0, v1 = v1, v0
+	}
+
+	return u0, v0, u1, v1
+
+}
+
+// CurrentFrameCenter returns the center of the current frame's rectangle on the spritesheet, of format (x, y), for drawing
+// sprites from their centers. If File.CurrentFrame() is nil, it will instead return (-1, -1).
+func (player *Player) CurrentFrameCenter() (float64, float64) {
+
+	if player.CurrentTag.IsEmpty() {
+		return -1, -1
+	}
+
+	rect := player.File.frameRect(player.FrameIndex)
+
+	return float64(rect.Min.X) + float64(rect.Dx())/2, float64(rect.Min.Y) + float64(rect.Dy())/2
+
+}
+
+// CurrentUVCenter returns the center of the current frame's rectangle on the spritesheet in UV space (0 to 1), of format
+// (u, v). If File.CurrentFrame() is nil, it will instead return (-1, -1).
+func (player *Player) CurrentUVCenter() (float64, float64) {
+
+	x, y := player.CurrentFrameCenter()
+
+	if x < 0 {
+		return -1, -1
+	}
+
+	return x / float64(player.File.Width), y / float64(player.File.Height)
+
+}
+
+// CurrentUVCoords returns the top-left corner of the current frame, of format (x, y). If File.CurrentFrame() is nil, it will instead
+// return (-1, -1).
+func (player *Player) CurrentUVCoords() (float64, float64) {
+
+	if frame, ok := player.CurrentFrame(); ok {
+		return float64(frame.X) / float64(player.File.Width), float64(frame.Y) / float64(player.File.Height)
+	}
+
+	return -1, -1
+
+}
+
+// FrameProgress returns how far playback is through the current frame, ranging from 0 (the frame was just reached) to 1 (the
+// frame is about to end). If no tag is playing, it returns -1.
+func (player *Player) FrameProgress() float32 {
+
+	duration, ok := player.CurrentFrameDuration()
+
+	if !ok {
+		return -1
+	}
+
+	if duration <= 0 {
+		return 0
+	}
+
+	progress := player.frameCounter / duration
+	if progress > 1 {
+		progress = 1
+	}
+
+	return progress
+
+}
+
+// InterpolatedUVCoords returns the top-left corner of the current frame in UV space, of format (x, y), blended toward the
+// top-left corner of the next frame (see NextFrameRect()) according to FrameProgress(). If no tag is playing, it will instead
+// return (-1, -1).
+func (player *Player) InterpolatedUVCoords() (float64, float64) {
+
+	u, v := player.CurrentUVCoords()
+
+	if u < 0 {
+		return -1, -1
+	}
+
+	next := player.NextFrameRect()
+	nextU := float64(next.Min.X) / float64(player.File.Width)
+	nextV := float64(next.Min.Y) / float64(player.File.Height)
+
+	t := float64(player.FrameProgress())
+
+	return u + (nextU-u)*t, v + (nextV-v)*t
+
+}
+
+// SetFrameIndexInAnimation sets the currently visible frame to frameIndex, using the playing animation as the range.
+// This means calling SetFrameIndexInAnimation with a frameIndex of 2 would set it to the third frame of the animation that is currently playing.
+func (player *Player) SetFrameIndexInAnimation(frameIndex int) {
+
+	if !player.CurrentTag.IsEmpty() {
+
+		player.FrameIndex = player.CurrentTag.Start + frameIndex
+		if player.FrameIndex > player.CurrentTag.End {
+			player.FrameIndex = player.CurrentTag.End
+		}
 		player.frameCounter = 0
 
-	}
+	}
+
+}
+
+// TimeUntilFrame returns the time, in seconds, until playback reaches the frame at the given index within the playing tag
+// (so an inTagIndex of 0 is the tag's Start frame), accounting for the PlaySpeed and the play direction. If playback is
+// already on the frame, 0 is returned. If wrap is false, only the rest of the current pass through the tag is considered;
+// if it's true, playback can loop around to reach the frame (unless the tag is on its last loop). If the frame can't be
+// reached (or is outside of the tag, is passed over because of SkipEmptyFrames, or no tag is playing), TimeUntilFrame
+// returns -1.
+func (player *Player) TimeUntilFrame(inTagIndex int, wrap bool) float32 {
+
+	tag := player.CurrentTag
+
+	if tag.IsEmpty() || inTagIndex < 0 || tag.Start+inTagIndex > tag.End {
+		return -1
+	}
+
+	target := tag.Start + inTagIndex
+
+	if player.FrameIndex == target {
+		return 0
+	}
+
+	speed := player.PlaySpeed * player.tagSpeed()
+
+	if player.finished || player.holding || speed <= 0 {
+		return -1
+	}
+
+	lastLoop := player.loops() > 0 && player.loopCount+1 >= player.loops()
+
+	if player.skipsFrame(target) {
+		return -1
+	}
+
+	sim := *player
+	elapsed := player.frameDuration(sim.FrameIndex) - sim.frameCounter
+
+	// Any frame in the tag is reached within two passes through it, so that's as far as we look.
+	for i := 0; i < 2*(tag.End-tag.Start+1); i++ {
+
+		step := sim.nextStep()
+
+		if step.looped && (!wrap || lastLoop) {
+			return -1
+		}
+
+		sim.applyStep(step)
+
+		if sim.FrameIndex == target {
+			return elapsed / speed
+		}
+
+		elapsed += player.frameDuration(sim.FrameIndex)
+
+	}
+
+	return -1
+
+}
+
+// SetTagProgress positions playback within the currently playing tag by fraction, where 0 is the start of the tag's first frame
+// (in play order) and 1 is the end of its last frame, according to the durations of the tag's Frames. The frame index is set
+// accordingly, and the time already spent on that frame is kept, so that playback continues seamlessly. Values outside of [0, 1]
+// are clamped. For ping-pong tags, the fraction maps to the forward pass through the tag.
+func (player *Player) SetTagProgress(p float32) {
+
+	tag := player.CurrentTag
+
+	if tag.IsEmpty() {
+		return
+	}
+
+	if p < 0 {
+		p = 0
+	} else if p > 1 {
+		p = 1
+	}
+
+	elapsed := p * tag.Duration()
+
+	frameIndex, direction, last := tag.Start, 1, tag.End
+	if player.tagDirection == PlayBackward {
+		frameIndex, direction, last = tag.End, -1, tag.Start
+	}
+
+	for frameIndex != last && elapsed >= player.File.Frames[frameIndex].Duration {
+		elapsed -= player.File.Frames[frameIndex].Duration
+		frameIndex += direction
+	}
+
+	player.FrameIndex = frameIndex
+	player.playDirection = direction
+	player.frameCounter = elapsed
+
+}
+
+// FrameIndexInAnimation returns the currently visible frame index, using the playing animation as the range.
+// This means that a FrameIndexInAnimation of 0 would be the first frame in the currently playing animation,
+// regardless of what frame in the sprite strip that is).
+// If no animation is being played, this function will return -1.
+func (player *Player) FrameIndexInAnimation() int {
+	if !player.CurrentTag.IsEmpty() {
+		return player.FrameIndex - player.CurrentTag.Start
+	}
+	return -1
+}
+
+// PlayingDefaultTag returns if the Player is playing the default ("") tag, which spans all of the File's frames (i.e. if
+// Play("") was called).
+func (player *Player) PlayingDefaultTag() bool {
+	return player.CurrentTag.isDefault()
+}
+
+// FrameAtPlaybackTime returns the index of the frame of the currently playing tag that would be shown the given number of
+// seconds after starting to play it, taking the play direction, looping, and the PlaySpeed into account, without altering the
+// Player's state. This is useful for rendering deterministically from a timeline or replay. If no tag is playing, it returns -1.
+func (player *Player) FrameAtPlaybackTime(t float32) int {
+	if player.CurrentTag.IsEmpty() {
+		return -1
+	}
+	return player.CurrentTag.frameAtTime(player.tagDirection, t*player.PlaySpeed*player.tagSpeed())
+}
+
+// FrameInfo returns the index of the current frame within the playing tag, the number of frames in the tag, and the absolute
+// index of the current frame (i.e. its index in File.Frames), for example for displaying "frame 3/8 (abs 11)" in a debug HUD.
+// If no tag is playing, all three values are -1.
+func (player *Player) FrameInfo() (inTag, tagLen, absolute int) {
+	if player.CurrentTag.IsEmpty() {
+		return -1, -1, -1
+	}
+	return player.FrameIndexInAnimation(), player.CurrentTag.End - player.CurrentTag.Start + 1, player.FrameIndex
+}
+
+// Open will use the provided file system to open and parse an Aseprite JSON file. Returns a *goaseprite.File.
+// This can be your starting point. Files created with Open() will put the JSON filepath used in the Path field.
+// Gzipped JSON files are detected automatically and decompressed before parsing. If the file can't be read (e.g. if it
+// doesn't exist), or its contents can't be parsed (see Read()), an error is returned instead.
+func Open(jsonPath string, fs fs.FS) (*File, error) {
+
+	data, err := readAll(jsonPath, fs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	asf, err := Read(data)
+
+	if err != nil {
+		return nil, err
+	}
+
+	asf.Path = jsonPath
+	return asf, nil
+
+}
+
+// OpenTagOnly will use the provided file system to open an Aseprite JSON file, parsing only the frames within the tag of
+// the given name. Frame indices (as well as Slice key frames) are re-based so that the tag's first frame is frame 0, and the
+// resulting File has a single Tag (with the given name) spanning all of its frames. This is useful for reducing parse time and
+// memory usage when only a small part of a big spritesheet is needed. As with Read(), ErrInvalidJSON is returned if the file
+// isn't valid Aseprite JSON; if the tag's range lies outside of the file's frames, an error wrapping ErrFrameOutOfRange is
+// returned.
+func OpenTagOnly(jsonPath, tagName string, fs fs.FS) (*File, error) {
+
+	data, err := readAll(jsonPath, fs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	json := trimJSON(string(data))
+
+	if !validJSON(json) {
+		return nil, ErrInvalidJSON
+	}
+
+	for _, anim := range gjson.Get(json, "meta.frameTags").Array() {
+
+		if anim.Get("name").Str == tagName {
+
+			frameNames := sortedFrameNames(json)
+
+			start, startOK := tagBound(anim.Get("from"), frameNames)
+			end, endOK := tagBound(anim.Get("to"), frameNames)
+
+			if !startOK || !endOK {
+				return nil, fmt.Errorf("%w: %q", ErrUnknownFrameName, tagName)
+			}
 
-}
+			if start < 0 || start > end || end >= len(frameNames) {
+				return nil, fmt.Errorf("%w: %q", ErrFrameOutOfRange, tagName)
+			}
+
+			tag := readTag(anim, start, end, nil)
+			asf, err := read(json, &tag)
+			if err != nil {
+				return nil, err
+			}
+			asf.Path = jsonPath
+			return asf, nil
+
+		}
 
-// FrameIndexInAnimation returns the currently visible frame index, using the playing animation as the range.
-// This means that a FrameIndexInAnimation of 0 would be the first frame in the currently playing animation,
-// regardless of what frame in the sprite strip that is).
-// If no animation is being played, this function will return -1.
-func (player *Player) FrameIndexInAnimation() int {
-	if !player.CurrentTag.IsEmpty() {
-		return player.FrameIndex - player.CurrentTag.Start
 	}
-	return -1
+
+	return nil, ErrNoTagByName
+
 }
 
-// Open will use the provided file system to open and parse an Aseprite JSON file. Returns a *goaseprite.File.
-// This can be your starting point. Files created with Open() will put the JSON filepath used in the Path field.
-func Open(jsonPath string, fs fs.FS) (*File, error) {
+// gzipMagic is the sequence of bytes that gzipped data starts with.
+var gzipMagic = []byte{0x1f, 0x8b}
 
-	// fileData, err := os.ReadFile(jsonPath)
+// readAll reads all of the bytes of the file at the given path from the provided file system, decompressing them if the file
+// is gzipped.
+func readAll(jsonPath string, fs fs.FS) ([]byte, error) {
 
 	fileData, err := fs.Open(jsonPath)
 
@@ -379,53 +2788,235 @@ func Open(jsonPath string, fs fs.FS) (*File, error) {
 		return nil, err
 	}
 
-	bytes, err := io.ReadAll(fileData)
+	defer fileData.Close()
+
+	data, err := io.ReadAll(fileData)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(data, gzipMagic) {
+
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+
+		if err != nil {
+			return nil, err
+		}
+
+		defer reader.Close()
+
+		return io.ReadAll(reader)
+
+	}
+
+	return data, nil
+
+}
+
+// Read returns a *goaseprite.File for a given sequence of bytes read from an Aseprite JSON file. If the data is empty, isn't
+// valid JSON, or doesn't have a "frames" object with at least one frame in it, ErrInvalidJSON is returned instead of a
+// partially filled-in File. Tags whose bounds are given as frame names (as some exporters write them) are resolved to frame
+// indices; if a Tag references a frame name that doesn't exist, an error wrapping ErrUnknownFrameName is returned, and if a
+// Tag's range lies outside of the File's frames, an error wrapping ErrFrameOutOfRange is returned.
+func Read(fileData []byte) (*File, error) {
+
+	json := trimJSON(string(fileData))
+
+	if !validJSON(json) {
+		return nil, ErrInvalidJSON
+	}
+
+	asf, err := read(json, nil)
 
 	if err != nil {
 		return nil, err
 	}
 
-	asf := Read(bytes)
-	asf.Path = jsonPath
 	return asf, nil
 
 }
 
-// Read returns a *goaseprite.File for a given sequence of bytes read from an Aseprite JSON file.
-// This function assumes a properly formed Aseprite JSON file.
-func Read(fileData []byte) *File {
+// OpenDir uses the provided file system to open and parse every Aseprite JSON file (every file with a .json extension) in
+// the given directory, returning them in a map keyed by their filenames without the extension (so "player.json" is keyed
+// as "player"). JSON files that aren't valid Aseprite JSON are skipped. Subdirectories aren't searched.
+func OpenDir(dir string, fsys fs.FS) (map[string]*File, error) {
+
+	entries, err := fs.ReadDir(fsys, dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string]*File{}
+
+	for _, entry := range entries {
+
+		if entry.IsDir() || path.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		jsonPath := path.Join(dir, entry.Name())
+
+		data, err := readAll(jsonPath, fsys)
+
+		if err != nil {
+			return nil, err
+		}
+
+		asf, err := Read(data)
+
+		if errors.Is(err, ErrInvalidJSON) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		asf.Path = jsonPath
+		files[strings.TrimSuffix(entry.Name(), ".json")] = asf
+
+	}
+
+	return files, nil
+
+}
+
+// ReadMulti reads Aseprite JSON data that might contain multiple sprites, returning a *goaseprite.File for each in a map.
+// Some batch exports nest each sprite's "frames" and "meta" under its own top-level key (e.g. {"hero": {"frames": ...,
+// "meta": ...}, "enemy": {...}}); in that case, the map is keyed by those keys. For ordinary single-sprite data, the map
+// has a single entry, keyed by the sprite's image filename without the extension (e.g. "hero" for "hero.png"). An error is
+// returned if the data isn't valid JSON or doesn't contain any sprites, or if reading any of the sprites fails.
+func ReadMulti(fileData []byte) (map[string]*File, error) {
+
+	json := trimJSON(string(fileData))
+
+	if !gjson.Valid(json) {
+		return nil, ErrInvalidJSON
+	}
+
+	files := map[string]*File{}
+
+	if gjson.Get(json, "frames").Exists() {
+
+		asf, err := Read([]byte(json))
+
+		if err != nil {
+			return nil, err
+		}
+
+		name := filepath.Base(asf.ImagePath)
+		files[strings.TrimSuffix(name, filepath.Ext(name))] = asf
+
+		return files, nil
+
+	}
+
+	var err error
+
+	gjson.Parse(json).ForEach(func(key, value gjson.Result) bool {
+
+		if !value.IsObject() || !value.Get("frames").Exists() {
+			return true
+		}
+
+		var asf *File
+		asf, err = Read([]byte(value.Raw))
+		if err != nil {
+			return false
+		}
+
+		files[key.String()] = asf
+		return true
+
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(files) == 0 {
+		return nil, ErrInvalidJSON
+	}
+
+	return files, nil
+
+}
+
+// ReadString returns a *goaseprite.File for the given Aseprite JSON string. It behaves identically to Read().
+func ReadString(json string) (*File, error) {
+	return Read([]byte(json))
+}
+
+// trimJSON strips any UTF-8 byte order marks and whitespace from the start of the given JSON data, as files saved by some
+// editors and tools start with them.
+func trimJSON(json string) string {
+	return strings.TrimLeft(json, "\ufeff \t\r\n")
+}
+
+// validJSON returns if the given JSON data is valid enough to be read as an Aseprite spritesheet; that is, if it's valid JSON
+// with a "frames" object containing at least one frame.
+func validJSON(json string) bool {
+	if !gjson.Valid(json) {
+		return false
+	}
+	frames := gjson.Get(json, "frames")
+	return frames.IsObject() && len(frames.Map()) > 0
+}
+
+// isURL returns if the given image reference is a URL (e.g. "https://example.com/sprite.png") rather than a filesystem path.
+func isURL(path string) bool {
+	scheme := strings.SplitN(path, "://", 2)
+	if len(scheme) < 2 || scheme[0] == "" {
+		return false
+	}
+	for _, r := range scheme[0] {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// cleanImagePath cleans the given image reference if it's a filesystem path; URLs are left untouched, as cleaning them
+// would collapse the slashes after the scheme.
+func cleanImagePath(path string) string {
+	if isURL(path) {
+		return path
+	}
+	return filepath.Clean(path)
+}
 
-	json := string(fileData)
+// read parses the given Aseprite JSON data into a *File. If onlyTag is non-nil, only the frames within onlyTag's range (which
+// must lie within the data's frames) are parsed, and the resulting File contains onlyTag (re-based to start at frame 0) as its
+// only Tag. If a Tag's bounds reference frame names that don't exist, or lie outside of the data's frames, no File is
+// returned; instead, an error wrapping ErrUnknownFrameName or ErrFrameOutOfRange (respectively) is returned.
+func read(json string, onlyTag *Tag) (*File, error) {
 
 	ase := &File{
 		Tags:      []Tag{},
-		ImagePath: filepath.Clean(gjson.Get(json, "meta.image").String()),
+		ImagePath: cleanImagePath(gjson.Get(json, "meta.image").String()),
 	}
 
-	frameNames := []string{}
+	frameNames := sortedFrameNames(json)
 
-	ase.Width = int32(gjson.Get(json, "meta.size.w").Num)
-	ase.Height = int32(gjson.Get(json, "meta.size.h").Num)
+	ase.Width = int32(gjson.Get(json, "meta.size.w").Int())
+	ase.Height = int32(gjson.Get(json, "meta.size.h").Int())
+	ase.Format = gjson.Get(json, "meta.format").String()
 
 	for _, key := range gjson.Get(json, "meta.layers").Array() {
-		ase.Layers = append(ase.Layers, Layer{Name: key.Get("name").String(), Opacity: uint8(key.Get("opacity").Int()), BlendMode: key.Get("blendMode").String()})
-	}
 
-	for key := range gjson.Get(json, "frames").Map() {
-		frameNames = append(frameNames, key)
+		layerType := key.Get("type").String()
+		if layerType == "" {
+			layerType = "normal"
+		}
+
+		ase.Layers = append(ase.Layers, Layer{Name: key.Get("name").String(), Opacity: uint8(key.Get("opacity").Int()), BlendMode: key.Get("blendMode").String(), Type: layerType, Group: key.Get("group").String(), Color: parseColor(key.Get("color").String())})
+
 	}
 
-	sort.Slice(frameNames, func(i, j int) bool {
-		x := frameNames[i]
-		y := frameNames[j]
-		xfi := strings.LastIndex(x, " ") + 1
-		xli := strings.LastIndex(x, ".")
-		xv, _ := strconv.ParseInt(x[xfi:xli], 10, 32)
-		yfi := strings.LastIndex(y, " ") + 1
-		yli := strings.LastIndex(y, ".")
-		yv, _ := strconv.ParseInt(y[yfi:yli], 10, 32)
-		return xv < yv
-	})
+	if onlyTag != nil {
+		frameNames = frameNames[onlyTag.Start : onlyTag.End+1]
+	}
 
 	for _, key := range frameNames {
 
@@ -434,9 +3025,20 @@ func Read(fileData []byte) *File {
 		frameData := gjson.Get(json, "frames."+frameName)
 
 		frame := Frame{}
+		frame.Name = key
 		frame.X = int(frameData.Get("frame.x").Num)
 		frame.Y = int(frameData.Get("frame.y").Num)
-		frame.Duration = float32(frameData.Get("duration").Num) / 1000
+		frame.W = int(frameData.Get("frame.w").Num)
+		frame.H = int(frameData.Get("frame.h").Num)
+		frame.Duration = float32(frameData.Get("duration").Num * DurationScale)
+
+		frame.Opacity = 255
+		if opacity := frameData.Get("opacity"); opacity.Exists() {
+			frame.Opacity = uint8(opacity.Int())
+		}
+
+		frame.OffsetX = int(frameData.Get("position.x").Int())
+		frame.OffsetY = int(frameData.Get("position.y").Int())
 
 		ase.Frames = append(ase.Frames, frame)
 
@@ -448,6 +3050,10 @@ func Read(fileData []byte) *File {
 
 	}
 
+	if onlyTag != nil {
+		return readTagOnly(json, ase, *onlyTag), nil
+	}
+
 	// Default ("") animation
 	ase.Tags = append(ase.Tags, Tag{
 		Name:      "",
@@ -460,39 +3066,293 @@ func Read(fileData []byte) *File {
 	for _, anim := range gjson.Get(json, "meta.frameTags").Array() {
 
 		animName := anim.Get("name").Str
-		ase.Tags = append(ase.Tags, Tag{
-			Name:      animName,
-			Start:     int(anim.Get("from").Num),
-			End:       int(anim.Get("to").Num),
-			Direction: anim.Get("direction").Str,
-			File:      ase,
-		})
+
+		start, startOK := tagBound(anim.Get("from"), frameNames)
+		end, endOK := tagBound(anim.Get("to"), frameNames)
+
+		if !startOK || !endOK {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownFrameName, animName)
+		}
+
+		if start < 0 || start > end || end >= len(ase.Frames) {
+			return nil, fmt.Errorf("%w: %q", ErrFrameOutOfRange, animName)
+		}
+
+		ase.Tags = append(ase.Tags, readTag(anim, start, end, ase))
 
 	}
 
-	for _, sliceData := range gjson.Get(json, "meta.slices").Array() {
+	ase.Slices = readSlices(json)
+
+	return ase, nil
+
+}
+
+// readTag returns the Tag described by the given Tag JSON data, spanning the given (already resolved) frame range and
+// belonging to the given File.
+func readTag(anim gjson.Result, start, end int, file *File) Tag {
+
+	tag := Tag{
+		Name:      anim.Get("name").Str,
+		Start:     start,
+		End:       end,
+		Direction: readTagDirection(anim),
+		File:      file,
+		Color:     parseColor(anim.Get("color").Str),
+	}
+
+	if ParseTagData {
+		tag.data = anim.Get("data").Str
+	}
+
+	return tag
+
+}
+
+// tagDirectionKeys are the names a Tag's direction can be stored under, in order of preference. Aseprite itself exports
+// "direction", but some community export scripts write "aniDir" or "animationDirection" instead.
+var tagDirectionKeys = []string{"direction", "aniDir", "animationDirection"}
+
+// readTagDirection returns the direction of the given Tag's JSON data, defaulting to PlayForward if it has none.
+func readTagDirection(anim gjson.Result) string {
+	for _, name := range tagDirectionKeys {
+		if direction := anim.Get(name); direction.Exists() {
+			return direction.Str
+		}
+	}
+	return PlayForward
+}
+
+// sortedFrameNames returns the names of the frames in the given Aseprite JSON data, sorted by frame number.
+func sortedFrameNames(json string) []string {
+
+	frameNames := []string{}
+
+	for key := range gjson.Get(json, "frames").Map() {
+		frameNames = append(frameNames, key)
+	}
+
+	sort.Slice(frameNames, func(i, j int) bool {
+		xv := frameNumber(frameNames[i])
+		yv := frameNumber(frameNames[j])
+		if xv == yv {
+			return frameNames[i] < frameNames[j]
+		}
+		return xv < yv
+	})
+
+	return frameNames
+
+}
+
+// tagBound returns the frame index referenced by the given "from" or "to" value of a Tag, and if it could be resolved. The
+// value is usually a frame index (which is offset by TagIndexBase), but some exporters reference frames by name (e.g.
+// "walk 0") instead; names are resolved against the given frame names, ignoring the names' extensions.
+func tagBound(value gjson.Result, frameNames []string) (int, bool) {
+
+	if value.Type != gjson.String {
+		return int(value.Num) - TagIndexBase, true
+	}
+
+	if index, err := strconv.Atoi(strings.TrimSpace(value.Str)); err == nil {
+		return index - TagIndexBase, true
+	}
+
+	for i, name := range frameNames {
+		if name == value.Str || strings.TrimSuffix(name, filepath.Ext(name)) == value.Str {
+			return i, true
+		}
+	}
+
+	return 0, false
+
+}
+
+// readTagOnly finishes parsing a File that only contains the frames within the given tag, adding the tag (re-based to start
+// at frame 0) as the File's only Tag, and re-basing the Slices' keys to match.
+func readTagOnly(json string, ase *File, tag Tag) *File {
+
+	rebased := tag
+	rebased.Start = 0
+	rebased.End = len(ase.Frames) - 1
+	rebased.File = ase
+	ase.Tags = append(ase.Tags, rebased)
+
+	for _, slice := range readSlices(json) {
+
+		keys := []SliceKey{}
+
+		for _, key := range slice.Keys {
+
+			if int(key.Frame) > tag.End {
+				continue
+			}
+
+			key.Frame -= int32(tag.Start)
+
+			// A key from before the tag is still in effect on the tag's first frame, until the next key replaces it.
+			if key.Frame <= 0 {
+				key.Frame = 0
+				if len(keys) > 0 && keys[0].Frame == 0 {
+					keys[0] = key
+					continue
+				}
+			}
+
+			keys = append(keys, key)
+
+		}
+
+		if len(keys) > 0 {
+			slice.Keys = keys
+			ase.Slices = append(ase.Slices, slice)
+		}
+
+	}
+
+	return ase
+
+}
+
+// frameNumber returns the frame number from the given frame name, which is usually of the form "sprite 12.aseprite", but can
+// also be a bare integer ("12"), or lack the extension ("sprite 12"). If no frame number can be found, -1 is returned.
+func frameNumber(frameName string) int64 {
+
+	number := frameName[strings.LastIndex(frameName, " ")+1:]
+
+	if dot := strings.LastIndex(number, "."); dot >= 0 {
+		number = number[:dot]
+	}
+
+	value, err := strconv.ParseInt(number, 10, 32)
+	if err != nil {
+		return -1
+	}
+
+	return value
+
+}
+
+// parseColor parses a hex color string as exported by Aseprite ("#RRGGBBAA") into an int64 in RRGGBBAA format. Colors
+// without an alpha component ("#RRGGBB") are treated as fully opaque. Empty or malformed colors return 0.
+func parseColor(hex string) int64 {
+
+	hex = strings.TrimPrefix(hex, "#")
+
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	if len(hex) != 8 {
+		return 0
+	}
+
+	color, err := strconv.ParseInt(hex, 16, 64)
+	if err != nil {
+		return 0
+	}
+
+	return color
+
+}
+
+// parseDataPairs parses user data of the form "key:value;key:value" into a map. Entries without a ":" are skipped.
+func parseDataPairs(data string) map[string]string {
 
-		color, _ := strconv.ParseInt("0x"+sliceData.Get("color").Str[1:], 0, 64)
+	pairs := map[string]string{}
+
+	for _, entry := range strings.Split(data, ";") {
+		if i := strings.Index(entry, ":"); i >= 0 {
+			pairs[strings.TrimSpace(entry[:i])] = strings.TrimSpace(entry[i+1:])
+		}
+	}
+
+	return pairs
+
+}
+
+// dataValue returns the value of the given key within the given key:value pair data (as parsed by parseDataPairs()), and
+// if the key exists. If the key is given more than once, the last value wins. Unlike parseDataPairs(), it doesn't allocate.
+func dataValue(data, key string) (string, bool) {
+
+	value, exists := "", false
+
+	for data != "" {
+
+		entry := data
+		if i := strings.Index(data, ";"); i >= 0 {
+			entry, data = data[:i], data[i+1:]
+		} else {
+			data = ""
+		}
+
+		if i := strings.Index(entry, ":"); i >= 0 && strings.TrimSpace(entry[:i]) == key {
+			value, exists = strings.TrimSpace(entry[i+1:]), true
+		}
+
+	}
+
+	return value, exists
+
+}
+
+// ninePatchKeys are the names a Slice key's nine-patch center rectangle can be stored under, in order of preference.
+// Current versions of Aseprite export it as "center"; some older versions exported it as "9slice".
+var ninePatchKeys = []string{"center", "9slice"}
+
+// ninePatchCenter returns the nine-patch center rectangle of the given Slice key, checking each of the supported schemas.
+func ninePatchCenter(sdKey gjson.Result) gjson.Result {
+	for _, name := range ninePatchKeys {
+		if center := sdKey.Get(name); center.Exists() {
+			return center
+		}
+	}
+	return gjson.Result{}
+}
+
+// readSlices parses the Slices present in the given Aseprite JSON data.
+func readSlices(json string) []Slice {
+
+	slices := []Slice{}
+
+	for _, sliceData := range gjson.Get(json, "meta.slices").Array() {
 
 		newSlice := Slice{
 			Name:  sliceData.Get("name").Str,
 			Data:  sliceData.Get("data").Str,
-			Color: color,
+			Color: parseColor(sliceData.Get("color").Str),
 		}
 
 		for _, sdKey := range sliceData.Get("keys").Array() {
+
+			center := ninePatchCenter(sdKey)
+			if center.Exists() {
+				newSlice.IsNinePatch = true
+			}
+
 			newSlice.Keys = append(newSlice.Keys, SliceKey{
 				Frame: int32(sdKey.Get("frame").Int()),
 				X:     int(sdKey.Get("bounds.x").Int()),
 				Y:     int(sdKey.Get("bounds.y").Int()),
 				W:     int(sdKey.Get("bounds.w").Int()),
 				H:     int(sdKey.Get("bounds.h").Int()),
+				XF:    sdKey.Get("bounds.x").Float(),
+				YF:    sdKey.Get("bounds.y").Float(),
+				WF:    sdKey.Get("bounds.w").Float(),
+				HF:    sdKey.Get("bounds.h").Float(),
+				NinePatchCenter: image.Rect(
+					int(center.Get("x").Int()),
+					int(center.Get("y").Int()),
+					int(center.Get("x").Int()+center.Get("w").Int()),
+					int(center.Get("y").Int()+center.Get("h").Int()),
+				),
 			})
+
 		}
 
-		ase.Slices = append(ase.Slices, newSlice)
+		slices = append(slices, newSlice)
 	}
 
-	return ase
+	return slices
 
 }
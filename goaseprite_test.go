@@ -0,0 +1,3010 @@
+package goaseprite
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// sheetJSON builds the JSON data of a spritesheet of 16x16 frames laid out in a single row, with the given frame durations
+// (in milliseconds), frameTags entries, and any extra fields to add to "meta".
+func sheetJSON(durations []int, frameTags, meta string) string {
+
+	frames := []string{}
+
+	for i, duration := range durations {
+		frames = append(frames, fmt.Sprintf(`"sheet %d.aseprite": {"frame": {"x": %d, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": %d}`, i, i*16, duration))
+	}
+
+	if meta != "" {
+		meta = ", " + meta
+	}
+
+	return fmt.Sprintf(`{"frames": {%s}, "meta": {"image": "sheet.png", "format": "RGBA8888", "size": {"w": %d, "h": 16}, "frameTags": [%s]%s}}`,
+		strings.Join(frames, ", "), 16*len(durations), frameTags, meta)
+
+}
+
+// readSheet parses the given JSON data, failing the test if it can't be read.
+func readSheet(t *testing.T, json string) *File {
+	t.Helper()
+	file, err := ReadString(json)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// openExample opens the example spritesheet, which has six frames (lasting 1000, 50, and then 100 milliseconds each), an
+// "idle" tag spanning frames 0-1, and a "walk" tag spanning frames 2-5.
+func openExample(t *testing.T) *File {
+	t.Helper()
+	file, err := Open("16x16Deliveryman.json", os.DirFS("example"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestLayerType(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100}, "", `"layers": [
+		{"name": "Body", "opacity": 255, "blendMode": "normal"},
+		{"name": "Ground", "opacity": 255, "blendMode": "normal", "type": "tilemap", "tileset": 0}
+	]`))
+
+	if file.Layers[0].Type != "normal" {
+		t.Errorf("expected a layer without a type to default to \"normal\", got %q", file.Layers[0].Type)
+	}
+
+	if file.Layers[1].Type != "tilemap" {
+		t.Errorf("expected the tilemap layer's type to be \"tilemap\", got %q", file.Layers[1].Type)
+	}
+
+}
+
+func TestOnReachFrame(t *testing.T) {
+
+	player := openExample(t).CreatePlayerPlaying("walk")
+
+	first, second := 0, 0
+	player.OnReachFrame(3, func() { first++ })
+	player.OnReachFrame(3, func() { second++ })
+
+	// Two full loops through the 4-frame walk tag, at 100ms per frame.
+	for i := 0; i < 8; i++ {
+		player.Update(0.1)
+	}
+
+	if first != 2 || second != 2 {
+		t.Errorf("expected both callbacks to fire once per arrival (2 times), got %d and %d", first, second)
+	}
+
+	player.ClearReachFrame(3)
+
+	for i := 0; i < 4; i++ {
+		player.Update(0.1)
+	}
+
+	if first != 2 || second != 2 {
+		t.Errorf("expected cleared callbacks not to fire, got %d and %d", first, second)
+	}
+
+}
+
+func TestCrossfadeTo(t *testing.T) {
+
+	file := openExample(t)
+	player := file.CreatePlayerPlaying("idle")
+
+	if err := player.CrossfadeTo("walk", 0.2); err != nil {
+		t.Fatal(err)
+	}
+
+	from, to, progress, active := player.TransitionFrames()
+
+	if !active || progress != 0 {
+		t.Errorf("expected an active transition at t = 0, got active = %t, t = %f", active, progress)
+	}
+
+	player.Update(0.1)
+
+	from, to, progress, active = player.TransitionFrames()
+
+	if !active || progress != 0.5 {
+		t.Errorf("expected an active transition at t = 0.5, got active = %t, t = %f", active, progress)
+	}
+
+	if from != file.frameRect(0) {
+		t.Errorf("expected the outgoing frame to be idle's first frame %v, got %v", file.frameRect(0), from)
+	}
+
+	if to != file.frameRect(3) {
+		t.Errorf("expected the incoming frame to be walk's second frame %v, got %v", file.frameRect(3), to)
+	}
+
+	player.Update(0.1)
+
+	if _, _, _, active = player.TransitionFrames(); active {
+		t.Error("expected the transition to be over")
+	}
+
+	if player.CurrentTag.Name != "walk" || player.FrameIndex != 4 {
+		t.Errorf("expected the Player to snap to walk's frame 4, got %q frame %d", player.CurrentTag.Name, player.FrameIndex)
+	}
+
+}
+
+func TestSetSeed(t *testing.T) {
+
+	file := openExample(t)
+
+	sequence := func(player *Player) []string {
+		names := []string{}
+		for i := 0; i < 20; i++ {
+			if err := player.PlayRandom("", "idle", "walk"); err != nil {
+				t.Fatal(err)
+			}
+			names = append(names, player.CurrentTag.Name)
+		}
+		return names
+	}
+
+	a, b := file.CreatePlayer(), file.CreatePlayer()
+	a.SetSeed(42)
+	b.SetSeed(42)
+
+	first, second := sequence(a), sequence(b)
+
+	if strings.Join(first, ",") != strings.Join(second, ",") {
+		t.Errorf("expected identical seeds to produce identical sequences, got %v and %v", first, second)
+	}
+
+	c := file.CreatePlayer()
+	c.SetRand(rand.New(rand.NewSource(42)))
+
+	if third := sequence(c); strings.Join(first, ",") != strings.Join(third, ",") {
+		t.Errorf("expected an injected generator with the same seed to produce the same sequence, got %v and %v", first, third)
+	}
+
+}
+
+func TestOpenTagOnly(t *testing.T) {
+
+	file, err := OpenTagOnly("16x16Deliveryman.json", "walk", os.DirFS("example"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(file.Frames) != 4 {
+		t.Fatalf("expected only walk's 4 frames to be loaded, got %d", len(file.Frames))
+	}
+
+	if file.Frames[0].X != 32 || file.Frames[3].X != 80 {
+		t.Errorf("expected the loaded frames to be walk's frames (x = 32 to 80), got x = %d to %d", file.Frames[0].X, file.Frames[3].X)
+	}
+
+	if len(file.Tags) != 1 || file.Tags[0].Name != "walk" || file.Tags[0].Start != 0 || file.Tags[0].End != 3 {
+		t.Errorf("expected a single walk tag spanning frames 0-3, got %+v", file.Tags)
+	}
+
+	fsys := fstest.MapFS{
+		"broken.json": {Data: []byte("not json")},
+		"range.json":  {Data: []byte(sheetJSON([]int{100}, `{"name": "far", "from": 5, "to": 7}`, ""))},
+	}
+
+	if _, err := OpenTagOnly("broken.json", "walk", fsys); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("expected ErrInvalidJSON for non-JSON data, got %v", err)
+	}
+
+	if _, err := OpenTagOnly("range.json", "far", fsys); !errors.Is(err, ErrFrameOutOfRange) {
+		t.Errorf("expected ErrFrameOutOfRange for a tag outside of the file's frames, got %v", err)
+	}
+
+	if _, err := OpenTagOnly("range.json", "missing", fsys); !errors.Is(err, ErrNoTagByName) {
+		t.Errorf("expected ErrNoTagByName for a missing tag, got %v", err)
+	}
+
+	defer func(parse bool) { ParseTagData = parse }(ParseTagData)
+	ParseTagData = true
+
+	fsys["dash.json"] = &fstest.MapFile{Data: []byte(sheetJSON([]int{100, 100, 100},
+		`{"name": "dash", "from": 1, "to": 2, "direction": "forward", "color": "#ff0000", "data": "speed:2"}`, ""))}
+
+	file, err = OpenTagOnly("dash.json", "dash", fsys)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dash := file.Tags[0]; dash.Color != 0xff0000ff || dash.speed() != 2 {
+		t.Errorf("expected the tag's color and user data to be kept, got color %08x and speed %f", dash.Color, dash.speed())
+	}
+
+}
+
+func TestOpenGzipped(t *testing.T) {
+
+	data, err := os.ReadFile(filepath.Join("example", "16x16Deliveryman.json"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compressed := bytes.Buffer{}
+	writer := gzip.NewWriter(&compressed)
+	writer.Write(data)
+	writer.Close()
+
+	dir := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(dir, "sprite.json.gz"), compressed.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := Open("sprite.json.gz", os.DirFS(dir))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := file.TagByName("walk"); len(file.Frames) != 6 || !ok {
+		t.Errorf("expected the gzipped file to read the same as the plain one, got %d frames and tags %+v", len(file.Frames), file.Tags)
+	}
+
+	if plain := openExample(t); len(plain.Frames) != len(file.Frames) {
+		t.Errorf("expected plain JSON to keep working, got %d frames", len(plain.Frames))
+	}
+
+}
+
+func TestNextFrameRect(t *testing.T) {
+
+	file := openExample(t)
+	player := file.CreatePlayerPlaying("walk")
+
+	if next := player.NextFrameRect(); next != file.frameRect(3) {
+		t.Errorf("expected the next frame to be frame 3 %v, got %v", file.frameRect(3), next)
+	}
+
+	// Advance to walk's last frame, right before the loop boundary.
+	for i := 0; i < 3; i++ {
+		player.Update(0.1)
+	}
+
+	if next := player.NextFrameRect(); next != file.frameRect(2) {
+		t.Errorf("expected the next frame to wrap around to frame 2 %v, got %v", file.frameRect(2), next)
+	}
+
+	player.Pause()
+
+	if next := player.NextFrameRect(); next != file.frameRect(5) {
+		t.Errorf("expected a paused Player's next frame to be the current one %v, got %v", file.frameRect(5), next)
+	}
+
+	player.Resume()
+	player.JumpToTagEnd()
+
+	if next := player.NextFrameRect(); next != file.frameRect(player.FrameIndex) {
+		t.Errorf("expected a finished Player's next frame to be the current one %v, got %v", file.frameRect(player.FrameIndex), next)
+	}
+
+	if next := (&Player{File: file}).NextFrameRect(); !next.Empty() {
+		t.Errorf("expected an empty rectangle when no tag is playing, got %v", next)
+	}
+
+}
+
+func TestFrameNames(t *testing.T) {
+
+	durations := make([]int, 12)
+	for i := range durations {
+		durations[i] = 100
+	}
+
+	names := readSheet(t, sheetJSON(durations, "", "")).FrameNames()
+
+	if len(names) != 12 {
+		t.Fatalf("expected 12 frame names, got %d", len(names))
+	}
+
+	// Frame names are sorted by their frame numbers, not lexically, so "sheet 10" comes after "sheet 9".
+	for i, name := range names {
+		if expected := fmt.Sprintf("sheet %d.aseprite", i); name != expected {
+			t.Errorf("expected frame %d to be named %q, got %q", i, expected, name)
+		}
+	}
+
+}
+
+func TestParseTagData(t *testing.T) {
+
+	json := sheetJSON([]int{100, 100, 100}, `{"name": "dash", "from": 0, "to": 2, "direction": "forward", "data": "speed:2; hold:1"}`, "")
+
+	if tag, _ := readSheet(t, json).TagByName("dash"); tag.Meta() != nil {
+		t.Errorf("expected tag data not to be parsed unless ParseTagData is set, got %v", tag.Meta())
+	}
+
+	defer func(parse bool) { ParseTagData = parse }(ParseTagData)
+	ParseTagData = true
+
+	file := readSheet(t, json)
+	tag, _ := file.TagByName("dash")
+
+	if meta := tag.Meta(); meta["speed"] != "2" || meta["hold"] != "1" {
+		t.Errorf("expected the tag's data to be parsed into speed:2 and hold:1, got %v", meta)
+	}
+
+	// Tags stay comparable, so they can be compared directly and used as map keys.
+	if other, _ := file.TagByName("dash"); tag != other || len(map[Tag]bool{tag: true}) != 1 {
+		t.Error("expected a Tag to compare equal to itself")
+	}
+
+	player := file.CreatePlayerPlaying("dash")
+	player.Update(0.05)
+
+	if player.FrameIndex != 1 {
+		t.Errorf("expected the tag's speed of 2 to advance a 100ms frame after 50ms, got frame %d", player.FrameIndex)
+	}
+
+	for _, data := range []string{"speed:0", "speed:-2", "speed:abc", "speed:+Inf"} {
+		tag, _ := readSheet(t, strings.Replace(json, "speed:2", data, 1)).TagByName("dash")
+		if speed := tag.speed(); speed != 1 {
+			t.Errorf("expected the invalid tag data %q to give a speed of 1, got %f", data, speed)
+		}
+	}
+
+}
+
+func TestCurrentSliceKeys(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100}, "", `"slices": [
+		{"name": "hitbox", "color": "#0000ffff", "keys": [
+			{"frame": 0, "bounds": {"x": 0, "y": 0, "w": 4, "h": 4}},
+			{"frame": 2, "bounds": {"x": 8, "y": 8, "w": 4, "h": 4}}
+		]},
+		{"name": "hurtbox", "color": "#ff0000ff", "keys": [
+			{"frame": 1, "bounds": {"x": 2, "y": 2, "w": 6, "h": 6}}
+		]}
+	]`))
+
+	player := file.CreatePlayerPlaying("")
+
+	keys := player.CurrentSliceKeys()
+
+	if len(keys) != 1 || keys["hitbox"].X != 0 {
+		t.Errorf("expected only hitbox's first key on frame 0, got %+v", keys)
+	}
+
+	player.Update(0.1)
+	keys = player.CurrentSliceKeys()
+
+	if len(keys) != 2 || keys["hitbox"].X != 0 || keys["hurtbox"].X != 2 {
+		t.Errorf("expected hitbox's first key to persist alongside hurtbox's key on frame 1, got %+v", keys)
+	}
+
+	player.Update(0.1)
+	keys = player.CurrentSliceKeys()
+
+	if len(keys) != 2 || keys["hitbox"].X != 8 || keys["hurtbox"].X != 2 {
+		t.Errorf("expected hitbox's second key and hurtbox's persisting key on frame 2, got %+v", keys)
+	}
+
+}
+
+func TestTagColor(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100}, `
+		{"name": "opaque", "from": 0, "to": 0, "direction": "forward", "color": "#ff8000"},
+		{"name": "alpha", "from": 1, "to": 1, "direction": "forward", "color": "#00ff0080"},
+		{"name": "none", "from": 2, "to": 2, "direction": "forward"}`, ""))
+
+	expected := map[string]int64{
+		"opaque": 0xff8000ff,
+		"alpha":  0x00ff0080,
+		"none":   0,
+	}
+
+	for name, color := range expected {
+		if tag, _ := file.TagByName(name); tag.Color != color {
+			t.Errorf("expected tag %q to have color %08x, got %08x", name, color, tag.Color)
+		}
+	}
+
+}
+
+func TestFitToDuration(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 300, 200}, `
+		{"name": "dash", "from": 0, "to": 2, "direction": "forward"},
+		{"name": "bounce", "from": 0, "to": 2, "direction": "pingpong"}`, ""))
+
+	// Both tags should loop once every second, even though bounce shows its middle frame twice per loop.
+	for _, name := range []string{"dash", "bounce"} {
+
+		player := file.CreatePlayerPlaying(name)
+		player.FitToDuration(1)
+
+		loops := []float32{}
+		elapsed := float32(0)
+
+		for len(loops) < 2 && elapsed < 10 {
+			player.Update(0.01)
+			elapsed += 0.01
+			if player.JustLooped() {
+				loops = append(loops, elapsed)
+			}
+		}
+
+		if len(loops) < 2 {
+			t.Fatalf("expected tag %q to loop twice within 10 seconds", name)
+		}
+
+		if length := loops[1] - loops[0]; length < 0.99 || length > 1.01 {
+			t.Errorf("expected tag %q to take 1 second per loop, took %f", name, length)
+		}
+
+		player.FitToDuration(0)
+
+		if player.PlaySpeed != 1 {
+			t.Errorf("expected FitToDuration(0) to revert to normal speed, got %f", player.PlaySpeed)
+		}
+
+	}
+
+	player := file.CreatePlayerPlaying("dash")
+	player.Loops = 1
+	player.FitToDuration(1)
+
+	elapsed := float32(0)
+	for !player.Finished() && elapsed < 10 {
+		player.Update(0.01)
+		elapsed += 0.01
+	}
+
+	if elapsed < 0.99 || elapsed > 1.01 {
+		t.Errorf("expected a single pass through dash to complete in 1 second, took %f", elapsed)
+	}
+
+}
+
+func TestEndHold(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100}, "", ""))
+	player := file.CreatePlayerPlaying("")
+	player.Loops = 1
+	player.EndHold = 0.5
+
+	finished := 0
+	player.OnFinish = func() { finished++ }
+
+	// The tag's three frames take 0.3 seconds, after which the last frame is held for another 0.5 seconds.
+	for i := 0; i < 7; i++ {
+		player.Update(0.1)
+	}
+
+	if finished != 0 || player.FrameIndex != 2 {
+		t.Errorf("expected the last frame to still be held without finishing, got frame %d and %d finishes", player.FrameIndex, finished)
+	}
+
+	player.Update(0.1)
+
+	if finished != 1 || !player.Finished() {
+		t.Errorf("expected OnFinish to fire once the hold elapsed, got %d finishes", finished)
+	}
+
+}
+
+func TestTagsByStart(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100, 100}, `
+		{"name": "late", "from": 3, "to": 3, "direction": "forward"},
+		{"name": "long", "from": 1, "to": 3, "direction": "forward"},
+		{"name": "short", "from": 1, "to": 2, "direction": "forward"}`, ""))
+
+	names := []string{}
+	for _, tag := range file.TagsByStart() {
+		names = append(names, tag.Name)
+	}
+
+	if order := strings.Join(names, ","); order != ",short,long,late" {
+		t.Errorf("expected tags sorted by start and then end (,short,long,late), got %s", order)
+	}
+
+	if file.Tags[1].Name != "late" {
+		t.Errorf("expected File.Tags to keep its file order, got %q first", file.Tags[1].Name)
+	}
+
+}
+
+func TestOrphanFrames(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100, 100, 100, 100}, `
+		{"name": "idle", "from": 1, "to": 2, "direction": "forward"},
+		{"name": "walk", "from": 4, "to": 4, "direction": "forward"}`, ""))
+
+	if orphans := fmt.Sprint(file.OrphanFrames()); orphans != "[0 3 5]" {
+		t.Errorf("expected frames 0, 3, and 5 to be orphaned, got %s", orphans)
+	}
+
+}
+
+func TestPlaySequencePingPong(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100, 100, 100, 100}, `
+		{"name": "a", "from": 0, "to": 1, "direction": "forward"},
+		{"name": "b", "from": 2, "to": 3, "direction": "forward"},
+		{"name": "c", "from": 4, "to": 5, "direction": "forward"}`, ""))
+
+	player := file.CreatePlayer()
+
+	entered := []string{}
+	player.OnTagEnter = func(tag Tag) { entered = append(entered, tag.Name) }
+
+	if err := player.PlaySequencePingPong("a", "b", "c"); err != nil {
+		t.Fatal(err)
+	}
+
+	entered = entered[:0]
+
+	frames := []int{player.FrameIndex}
+	for i := 0; i < 12; i++ {
+		player.Update(0.1)
+		frames = append(frames, player.FrameIndex)
+	}
+
+	if order := fmt.Sprint(frames); order != "[0 1 2 3 4 5 4 3 2 1 0 1 2]" {
+		t.Errorf("expected the sequence to play forward through a, b, and c, and then back, got %s", order)
+	}
+
+	if order := strings.Join(entered, ","); order != "b,c,b,a,b" {
+		t.Errorf("expected tags to be entered at each boundary in the order b,c,b,a,b, got %s", order)
+	}
+
+	// A reverse tag at the start of the sequence doesn't change the direction the sequence plays in.
+	reversed := readSheet(t, sheetJSON([]int{100, 100, 100, 100}, `
+		{"name": "a", "from": 0, "to": 1, "direction": "reverse"},
+		{"name": "b", "from": 2, "to": 3, "direction": "forward"}`, ""))
+
+	player = reversed.CreatePlayer()
+
+	if err := player.PlaySequencePingPong("a", "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if first, _, _ := player.TagBoundaryRects(); first != image.Rect(0, 0, 16, 16) {
+		t.Errorf("expected the reverse tag to be treated as playing forward within the sequence, got a first frame of %v", first)
+	}
+
+	frames = []int{player.FrameIndex}
+	for i := 0; i < 8; i++ {
+		player.Update(0.1)
+		frames = append(frames, player.FrameIndex)
+	}
+
+	if order := fmt.Sprint(frames); order != "[0 1 2 3 2 1 0 1 2]" {
+		t.Errorf("expected a sequence starting with a reverse tag to play forward and then back, got %s", order)
+	}
+
+	if player.CurrentTag.Name != "b" || player.tagDirection != PlayForward {
+		t.Errorf("expected b to be playing forward on the return leg, got %q playing %s", player.CurrentTag.Name, player.tagDirection)
+	}
+
+	if err := player.PlaySequencePingPong("a", "missing"); !errors.Is(err, ErrNoTagByName) {
+		t.Errorf("expected ErrNoTagByName for a missing tag, got %v", err)
+	}
+
+}
+
+func TestRemainingTime(t *testing.T) {
+
+	file := openExample(t)
+
+	if remaining := (&Player{File: file}).RemainingTime(); remaining != -1 {
+		t.Errorf("expected -1 when no tag is playing, got %f", remaining)
+	}
+
+	player := file.CreatePlayerPlaying("walk")
+
+	// Halfway through walk's second frame, with two more 100ms frames to go.
+	player.Update(0.15)
+
+	if remaining := player.RemainingTime(); math.Abs(float64(remaining-0.25)) > 0.0001 {
+		t.Errorf("expected 0.25 seconds to remain, got %f", remaining)
+	}
+
+	player.PlaySpeed = 2
+
+	if remaining := player.RemainingTime(); math.Abs(float64(remaining-0.125)) > 0.0001 {
+		t.Errorf("expected 0.125 seconds to remain at double speed, got %f", remaining)
+	}
+
+}
+
+func TestSliceFloatBounds(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100}, "", `"slices": [
+		{"name": "hitbox", "color": "#0000ffff", "keys": [{"frame": 0, "bounds": {"x": 1.5, "y": 2.25, "w": 3.75, "h": 4}}]}
+	]`))
+
+	key := file.Slices[0].Keys[0]
+
+	if key.XF != 1.5 || key.YF != 2.25 || key.WF != 3.75 || key.HF != 4 {
+		t.Errorf("expected fractional bounds (1.5, 2.25, 3.75, 4), got (%f, %f, %f, %f)", key.XF, key.YF, key.WF, key.HF)
+	}
+
+	if key.X != 1 || key.Y != 2 || key.W != 3 || key.H != 4 {
+		t.Errorf("expected truncated integer bounds (1, 2, 3, 4), got (%d, %d, %d, %d)", key.X, key.Y, key.W, key.H)
+	}
+
+}
+
+func TestSetTagProgress(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 300, 200}, `
+		{"name": "forward", "from": 0, "to": 2, "direction": "forward"},
+		{"name": "backward", "from": 0, "to": 2, "direction": "reverse"}`, ""))
+
+	player := file.CreatePlayerPlaying("forward")
+
+	// Half of the tag's 600ms is 300ms, which is 200ms into the 300ms middle frame.
+	player.SetTagProgress(0.5)
+
+	if player.FrameIndex != 1 {
+		t.Errorf("expected progress 0.5 to land on the middle frame, got frame %d", player.FrameIndex)
+	}
+
+	player.Update(0.1)
+
+	if player.FrameIndex != 2 {
+		t.Errorf("expected playback to continue from 200ms into the middle frame, got frame %d", player.FrameIndex)
+	}
+
+	player.SetTagProgress(2)
+
+	if player.FrameIndex != 2 {
+		t.Errorf("expected progress to be clamped to the last frame, got frame %d", player.FrameIndex)
+	}
+
+	player.Play("backward")
+	player.SetTagProgress(-1)
+
+	if player.FrameIndex != 2 {
+		t.Errorf("expected progress to be clamped to a reverse tag's first frame (2), got frame %d", player.FrameIndex)
+	}
+
+}
+
+func TestLayersExcept(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100}, "", `"layers": [
+		{"name": "Body", "opacity": 255, "blendMode": "normal"},
+		{"name": "reference", "opacity": 128, "blendMode": "normal"},
+		{"name": "Outline", "opacity": 255, "blendMode": "normal"}
+	]`))
+
+	names := []string{}
+	for _, layer := range file.LayersExcept("reference") {
+		names = append(names, layer.Name)
+	}
+
+	if order := strings.Join(names, ","); order != "Body,Outline" {
+		t.Errorf("expected the reference layer to be filtered out, got %s", order)
+	}
+
+	if opaque := file.FilterLayers(func(layer Layer) bool { return layer.Opacity == 255 }); len(opaque) != 2 {
+		t.Errorf("expected 2 opaque layers, got %d", len(opaque))
+	}
+
+}
+
+func TestJumpToTagEnd(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100, 100}, `
+		{"name": "open", "from": 1, "to": 3, "direction": "forward"},
+		{"name": "close", "from": 1, "to": 3, "direction": "reverse"}`, ""))
+
+	expected := map[string]int{"open": 3, "close": 1}
+
+	for name, frame := range expected {
+
+		player := file.CreatePlayerPlaying(name)
+
+		finished, changed := 0, 0
+		player.OnFinish = func() { finished++ }
+		player.OnFrameChange = func() { changed++ }
+
+		player.JumpToTagEnd()
+
+		if player.FrameIndex != frame {
+			t.Errorf("expected tag %q to rest on frame %d, got %d", name, frame, player.FrameIndex)
+		}
+
+		if !player.Finished() || finished != 1 || changed != 1 {
+			t.Errorf("expected tag %q to finish with one frame change, got finished = %t, %d finishes, %d frame changes", name, player.Finished(), finished, changed)
+		}
+
+		player.Update(0.5)
+
+		if player.FrameIndex != frame {
+			t.Errorf("expected tag %q to stay on frame %d after finishing, got %d", name, frame, player.FrameIndex)
+		}
+
+	}
+
+}
+
+func TestPingPongBounceTiming(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 300, 50}, `{"name": "bounce", "from": 0, "to": 2, "direction": "pingpong"}`, ""))
+	player := file.CreatePlayerPlaying("bounce")
+
+	// 0.5 seconds crosses frames 0 (100ms), 1 (300ms), and 2 (50ms), bouncing back to frame 1 with 50ms carried over.
+	player.Update(0.5)
+
+	if player.FrameIndex != 1 {
+		t.Fatalf("expected to have bounced back to frame 1, got frame %d", player.FrameIndex)
+	}
+
+	// The carried-over time should be measured against frame 1's 300ms, not frame 2's 50ms.
+	player.Update(0.24)
+
+	if player.FrameIndex != 1 {
+		t.Errorf("expected frame 1 to last its full 300ms after the bounce, got frame %d", player.FrameIndex)
+	}
+
+	player.Update(0.02)
+
+	if player.FrameIndex != 0 {
+		t.Errorf("expected to reach frame 0 once frame 1's 300ms elapsed, got frame %d", player.FrameIndex)
+	}
+
+}
+
+func ExampleFile_CanvasSize() {
+
+	file, err := Open("16x16Deliveryman.json", os.DirFS("example"))
+
+	if err != nil {
+		panic(err)
+	}
+
+	w, h := file.CanvasSize()
+
+	fmt.Println("Sheet:", file.Width, file.Height)
+	fmt.Println("Canvas:", w, h)
+
+	// Output:
+	// Sheet: 96 16
+	// Canvas: 16 16
+}
+
+func TestReadString(t *testing.T) {
+
+	json := sheetJSON([]int{100, 200}, `{"name": "idle", "from": 0, "to": 1, "direction": "forward"}`, "")
+
+	file, err := ReadString(json)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromBytes, _ := Read([]byte(json))
+
+	if len(file.Frames) != 2 || len(file.Tags) != len(fromBytes.Tags) || file.Frames[1].Duration != fromBytes.Frames[1].Duration {
+		t.Errorf("expected ReadString to read the same File as Read, got %+v and %+v", file, fromBytes)
+	}
+
+	if _, err := ReadString("{ not json"); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("expected ErrInvalidJSON for invalid JSON, got %v", err)
+	}
+
+}
+
+func TestRequireTags(t *testing.T) {
+
+	file := openExample(t)
+
+	if err := file.RequireTags("idle", "walk"); err != nil {
+		t.Errorf("expected no error when all tags exist, got %v", err)
+	}
+
+	err := file.RequireTags("idle", "jump", "attack")
+
+	if !errors.Is(err, ErrNoTagByName) {
+		t.Errorf("expected an error wrapping ErrNoTagByName, got %v", err)
+	}
+
+	if err == nil || !strings.Contains(err.Error(), `"jump"`) || strings.Contains(err.Error(), "attack") {
+		t.Errorf("expected the error to name the first missing tag (jump), got %v", err)
+	}
+
+}
+
+func TestTagFrameRect(t *testing.T) {
+
+	file := openExample(t)
+
+	if rect, ok := file.TagFrameRect("walk", 1); !ok || rect != image.Rect(48, 0, 64, 16) {
+		t.Errorf("expected walk's second frame to be at (48, 0)-(64, 16), got %v (ok = %t)", rect, ok)
+	}
+
+	for _, index := range []int{-1, 4} {
+		if _, ok := file.TagFrameRect("walk", index); ok {
+			t.Errorf("expected index %d to be out of walk's range", index)
+		}
+	}
+
+	if _, ok := file.TagFrameRect("missing", 0); ok {
+		t.Error("expected a missing tag not to return a rectangle")
+	}
+
+}
+
+func TestCreatePlayerPlaying(t *testing.T) {
+
+	file := openExample(t)
+
+	for _, name := range []string{"", "walk"} {
+
+		player := file.CreatePlayerPlaying(name)
+
+		if player.CurrentTag.IsEmpty() || player.CurrentTag.Name != name {
+			t.Errorf("expected the Player to be playing tag %q, got %q", name, player.CurrentTag.Name)
+		}
+
+		if x, _, _, _ := player.CurrentFrameCoords(); x == -1 {
+			t.Errorf("expected a valid frame right after creating a Player playing tag %q", name)
+		}
+
+	}
+
+}
+
+func TestTagSliceUnion(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100, 100}, `{"name": "swing", "from": 1, "to": 3, "direction": "forward"}`, `"slices": [
+		{"name": "blade", "color": "#0000ffff", "keys": [
+			{"frame": 0, "bounds": {"x": 0, "y": 0, "w": 2, "h": 2}},
+			{"frame": 1, "bounds": {"x": 4, "y": 2, "w": 4, "h": 4}},
+			{"frame": 2, "bounds": {"x": 8, "y": 4, "w": 4, "h": 4}},
+			{"frame": 3, "bounds": {"x": 10, "y": 10, "w": 2, "h": 2}}
+		]}
+	]`))
+
+	if union := file.TagSliceUnion("swing", "blade"); union != image.Rect(4, 2, 12, 12) {
+		t.Errorf("expected the blade's keys within swing to cover (4, 2)-(12, 12), got %v", union)
+	}
+
+	if union := file.TagSliceUnion("swing", "missing"); union != (image.Rectangle{}) {
+		t.Errorf("expected the zero rectangle for a missing slice, got %v", union)
+	}
+
+}
+
+func TestPaddedFrameRects(t *testing.T) {
+
+	// An export with a 1 pixel border and 2 pixels of spacing between frames; Aseprite bakes the padding into the positions.
+	file := readSheet(t, `{"frames": {
+		"padded 0.aseprite": {"frame": {"x": 1, "y": 1, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100},
+		"padded 1.aseprite": {"frame": {"x": 19, "y": 1, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100}
+	}, "meta": {"image": "padded.png", "size": {"w": 36, "h": 18}, "frameTags": []}}`)
+
+	expected := []image.Rectangle{image.Rect(1, 1, 17, 17), image.Rect(19, 1, 35, 17)}
+
+	for i, rect := range expected {
+		if got := file.frameRect(i); got != rect {
+			t.Errorf("expected frame %d to be at %v, got %v", i, rect, got)
+		}
+	}
+
+}
+
+func TestFlipMirrorsSlices(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100}, "", `"slices": [
+		{"name": "hitbox", "color": "#0000ffff", "keys": [{"frame": 0, "bounds": {"x": 2, "y": 1, "w": 4, "h": 6}}]}
+	]`))
+
+	player := file.CreatePlayerPlaying("")
+	player.FlipH = true
+
+	key := player.CurrentSliceKeys()["hitbox"]
+
+	if key.X != 10 || key.Y != 1 || key.W != 4 || key.H != 6 {
+		t.Errorf("expected the hitbox to be mirrored horizontally to (10, 1, 4, 6), got (%d, %d, %d, %d)", key.X, key.Y, key.W, key.H)
+	}
+
+	player.FlipH, player.FlipV = false, true
+
+	if key = player.CurrentSliceKeys()["hitbox"]; key.X != 2 || key.Y != 9 {
+		t.Errorf("expected the hitbox to be mirrored vertically to (2, 9), got (%d, %d)", key.X, key.Y)
+	}
+
+	if original := file.Slices[0].Keys[0]; original.X != 2 || original.Y != 1 {
+		t.Errorf("expected the File's slice keys to be left untouched, got (%d, %d)", original.X, original.Y)
+	}
+
+}
+
+func TestCurrentFrameDuration(t *testing.T) {
+
+	file := openExample(t)
+
+	if _, ok := (&Player{File: file}).CurrentFrameDuration(); ok {
+		t.Error("expected no duration when no tag is playing")
+	}
+
+	player := file.CreatePlayerPlaying("idle")
+
+	if duration, ok := player.CurrentFrameDuration(); !ok || duration != 1 {
+		t.Errorf("expected idle's first frame to last 1 second, got %f (ok = %t)", duration, ok)
+	}
+
+	player.Update(1)
+
+	if duration, _ := player.CurrentFrameDuration(); duration != 0.05 {
+		t.Errorf("expected idle's second frame to last 0.05 seconds, got %f", duration)
+	}
+
+}
+
+func TestIntegerKeyedFrames(t *testing.T) {
+
+	frames := []string{}
+	for _, i := range []int{10, 2, 0, 1, 9} {
+		frames = append(frames, fmt.Sprintf(`"%d": {"frame": {"x": %d, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100}`, i, i*16))
+	}
+
+	file := readSheet(t, `{"frames": {`+strings.Join(frames, ", ")+`}, "meta": {"image": "sheet.png", "size": {"w": 176, "h": 16}, "frameTags": []}}`)
+
+	if names := strings.Join(file.FrameNames(), ","); names != "0,1,2,9,10" {
+		t.Errorf("expected integer-keyed frames to be sorted numerically, got %s", names)
+	}
+
+	expected := map[string]int64{
+		"sprite 4.aseprite": 4,
+		"sprite 3":          3,
+		"walk.png":          -1,
+		"walk":              -1,
+		"":                  -1,
+	}
+
+	for name, number := range expected {
+		if got := frameNumber(name); got != number {
+			t.Errorf("expected frame name %q to have frame number %d, got %d", name, number, got)
+		}
+	}
+
+}
+
+func TestTogglePause(t *testing.T) {
+
+	player := openExample(t).CreatePlayerPlaying("walk")
+
+	player.TogglePause()
+
+	if !player.IsPaused() {
+		t.Error("expected the first toggle to pause the Player")
+	}
+
+	player.Update(0.1)
+
+	if player.FrameIndex != 2 {
+		t.Errorf("expected a paused Player not to advance, got frame %d", player.FrameIndex)
+	}
+
+	player.TogglePause()
+	player.Update(0.1)
+
+	if player.IsPaused() || player.FrameIndex != 3 {
+		t.Errorf("expected toggling twice to resume playback, got paused = %t, frame %d", player.IsPaused(), player.FrameIndex)
+	}
+
+}
+
+func TestAverageFPS(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 300, 100, 0}, `
+		{"name": "known", "from": 0, "to": 2, "direction": "forward"},
+		{"name": "instant", "from": 3, "to": 3, "direction": "forward"}`, ""))
+
+	// Three frames over 0.5 seconds.
+	if tag, _ := file.TagByName("known"); math.Abs(float64(tag.AverageFPS()-6)) > 0.0001 {
+		t.Errorf("expected an average of 6 FPS, got %f", tag.AverageFPS())
+	}
+
+	if tag, _ := file.TagByName("instant"); tag.AverageFPS() != 0 {
+		t.Errorf("expected a zero-duration tag to have an average of 0 FPS, got %f", tag.AverageFPS())
+	}
+
+}
+
+func TestOptimize(t *testing.T) {
+
+	// Frames 1-3 are duplicates (the same position and duration); frame 4 shares a position with them, but not a duration.
+	json := `{"frames": {
+		"dup 0.aseprite": {"frame": {"x": 0, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100},
+		"dup 1.aseprite": {"frame": {"x": 16, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100},
+		"dup 2.aseprite": {"frame": {"x": 16, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100},
+		"dup 3.aseprite": {"frame": {"x": 16, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100},
+		"dup 4.aseprite": {"frame": {"x": 16, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 200}
+	}, "meta": {"image": "dup.png", "size": {"w": 32, "h": 16}, "frameTags": [{"name": "wave", "from": 0, "to": 4, "direction": "forward"}]}}`
+
+	file := readSheet(t, json)
+	optimized := file.Optimize()
+
+	if len(optimized.Frames) != 3 || optimized.Frames[1].Duration != 0.3 {
+		t.Fatalf("expected frames 1-3 to be merged into a single 0.3 second frame, got %+v", optimized.Frames)
+	}
+
+	if tag, _ := optimized.TagByName("wave"); tag.Start != 0 || tag.End != 2 || tag.File != optimized {
+		t.Errorf("expected wave to be remapped to frames 0-2 of the optimized File, got %d-%d", tag.Start, tag.End)
+	}
+
+	// Both Files should show the same spritesheet rectangles at the same times.
+	original, merged := file.CreatePlayerPlaying("wave"), optimized.CreatePlayerPlaying("wave")
+
+	for i := 0; i < 20; i++ {
+		if a, b := original.File.frameRect(original.FrameIndex), merged.File.frameRect(merged.FrameIndex); a != b {
+			t.Fatalf("expected the optimized File to play identically, got %v and %v after %d updates", a, b, i)
+		}
+		original.Update(0.05)
+		merged.Update(0.05)
+	}
+
+	// Frames 2 and 3 are duplicates, but frame 3 is the End of a ping-pong tag, which shows it once per cycle (and frame 2
+	// twice), so merging them would change the cycle's length.
+	pingPong := readSheet(t, `{"frames": {
+		"pp 0.aseprite": {"frame": {"x": 0, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100},
+		"pp 1.aseprite": {"frame": {"x": 16, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100},
+		"pp 2.aseprite": {"frame": {"x": 32, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100},
+		"pp 3.aseprite": {"frame": {"x": 32, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100}
+	}, "meta": {"image": "pp.png", "size": {"w": 48, "h": 16}, "frameTags": [{"name": "bounce", "from": 0, "to": 3, "direction": "pingpong"}]}}`)
+	optimizedPingPong := pingPong.Optimize()
+
+	if len(optimizedPingPong.Frames) != 4 {
+		t.Errorf("expected a ping-pong tag's End frame not to be merged, got %d frames", len(optimizedPingPong.Frames))
+	}
+
+	original, merged = pingPong.CreatePlayerPlaying("bounce"), optimizedPingPong.CreatePlayerPlaying("bounce")
+
+	for i := 0; i < 20; i++ {
+		if a, b := original.File.frameRect(original.FrameIndex), merged.File.frameRect(merged.FrameIndex); a != b {
+			t.Fatalf("expected the optimized ping-pong tag to play identically, got %v and %v after %d updates", a, b, i)
+		}
+		original.Update(0.05)
+		merged.Update(0.05)
+	}
+
+	// Trimmed frames can share an origin on the spritesheet while differing in size.
+	trimmed := readSheet(t, `{"frames": {
+		"trim 0.aseprite": {"frame": {"x": 0, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100},
+		"trim 1.aseprite": {"frame": {"x": 0, "y": 0, "w": 8, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100}
+	}, "meta": {"image": "trim.png", "size": {"w": 16, "h": 16}}}`)
+
+	if frames := len(trimmed.Optimize().Frames); frames != 2 {
+		t.Errorf("expected frames of different sizes not to be merged, got %d frames", frames)
+	}
+
+	empty := (&File{Tags: []Tag{{Name: "", Start: 0, End: -1}}}).Optimize()
+
+	if len(empty.Frames) != 0 || len(empty.Tags) != 1 {
+		t.Errorf("expected optimizing a File with no frames to return an empty File, got %+v", empty)
+	}
+
+}
+
+func TestJustLooped(t *testing.T) {
+
+	player := openExample(t).CreatePlayerPlaying("walk")
+
+	for i := 1; i <= 8; i++ {
+
+		player.Update(0.1)
+
+		// Walk has four 100ms frames, so it wraps on every fourth update.
+		if looped := i%4 == 0; player.JustLooped() != looped {
+			t.Errorf("expected JustLooped() to be %t on update %d", looped, i)
+		}
+
+		if !player.JustChangedFrame() {
+			t.Errorf("expected JustChangedFrame() to be true on update %d", i)
+		}
+
+	}
+
+	player.Update(0.01)
+
+	if player.JustLooped() || player.JustChangedFrame() {
+		t.Error("expected both flags to reset on an update that doesn't change frames")
+	}
+
+}
+
+func TestReadWithBOM(t *testing.T) {
+
+	json := sheetJSON([]int{100, 100}, `{"name": "idle", "from": 0, "to": 1, "direction": "forward"}`, "")
+
+	for _, prefix := range []string{"\ufeff", "\r\n\t  ", "\ufeff\n"} {
+
+		file, err := Read([]byte(prefix + json))
+
+		if err != nil {
+			t.Errorf("expected data prefixed with %q to be read, got %v", prefix, err)
+			continue
+		}
+
+		if _, ok := file.TagByName("idle"); len(file.Frames) != 2 || !ok {
+			t.Errorf("expected data prefixed with %q to read 2 frames and the idle tag, got %d frames", prefix, len(file.Frames))
+		}
+
+	}
+
+}
+
+func TestInterpolatedUVCoords(t *testing.T) {
+
+	file := openExample(t)
+
+	if u, v := (&Player{File: file}).InterpolatedUVCoords(); u != -1 || v != -1 {
+		t.Errorf("expected -1, -1 when no tag is playing, got %f, %f", u, v)
+	}
+
+	player := file.CreatePlayerPlaying("walk")
+
+	// Halfway through frame 2 (u = 32 / 96), on the way to frame 3 (u = 48 / 96).
+	player.Update(0.05)
+
+	if u, v := player.InterpolatedUVCoords(); math.Abs(u-40.0/96) > 0.0001 || v != 0 {
+		t.Errorf("expected UV coordinates halfway between frames 2 and 3 (%f, 0), got %f, %f", 40.0/96, u, v)
+	}
+
+}
+
+func TestPlayUnknownTag(t *testing.T) {
+
+	file := openExample(t)
+
+	// By default (including for zero-value Players), the current tag keeps playing.
+	for _, player := range []*Player{file.CreatePlayer(), {File: file, PlaySpeed: 1}} {
+
+		player.Play("walk")
+
+		if err := player.Play("typo"); !errors.Is(err, ErrNoTagByName) {
+			t.Errorf("expected ErrNoTagByName, got %v", err)
+		}
+
+		if player.CurrentTag.Name != "walk" {
+			t.Errorf("expected walk to keep playing, got %q", player.CurrentTag.Name)
+		}
+
+	}
+
+	player := file.CreatePlayerPlaying("walk")
+	player.FallbackToDefaultTag = true
+
+	if err := player.Play("typo"); !errors.Is(err, ErrNoTagByName) {
+		t.Errorf("expected ErrNoTagByName even when falling back, got %v", err)
+	}
+
+	if player.CurrentTag.Name != "" {
+		t.Errorf("expected the Player to fall back to the default tag, got %q", player.CurrentTag.Name)
+	}
+
+	player.FallbackTag = "idle"
+	player.Play("typo")
+
+	if player.CurrentTag.Name != "idle" {
+		t.Errorf("expected FallbackTag to take precedence over FallbackToDefaultTag, got %q", player.CurrentTag.Name)
+	}
+
+}
+
+func TestSentinelErrors(t *testing.T) {
+
+	player := openExample(t).CreatePlayer()
+
+	if err := player.Play("missing"); !errors.Is(err, ErrNoTagByName) {
+		t.Errorf("expected Play to return ErrNoTagByName, got %v", err)
+	}
+
+	if err := player.PlayFromFrame("missing", 0); !errors.Is(err, ErrNoTagByName) {
+		t.Errorf("expected PlayFromFrame to return ErrNoTagByName, got %v", err)
+	}
+
+	if err := player.PlayFromFrame("walk", 10); !errors.Is(err, ErrFrameOutOfRange) {
+		t.Errorf("expected PlayFromFrame to return ErrFrameOutOfRange, got %v", err)
+	}
+
+	if ErrNoTagByName.Error() != ErrorNoTagByName {
+		t.Errorf("expected ErrNoTagByName's message to match the deprecated ErrorNoTagByName constant, got %q", ErrNoTagByName.Error())
+	}
+
+}
+
+func TestLayerTree(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100}, "", `"layers": [
+		{"name": "Character"},
+		{"name": "Head", "group": "Character"},
+		{"name": "Eyes", "group": "Head", "opacity": 255, "blendMode": "normal"},
+		{"name": "Body", "group": "Character", "opacity": 255, "blendMode": "normal"},
+		{"name": "Background", "opacity": 255, "blendMode": "normal"}
+	]`))
+
+	var describe func(nodes []*LayerNode) string
+	describe = func(nodes []*LayerNode) string {
+		names := []string{}
+		for _, node := range nodes {
+			name := node.Layer.Name
+			if len(node.Children) > 0 {
+				name += "(" + describe(node.Children) + ")"
+			}
+			names = append(names, name)
+		}
+		return strings.Join(names, " ")
+	}
+
+	if tree := describe(file.LayerTree()); tree != "Character(Head(Eyes) Body) Background" {
+		t.Errorf("expected the tree Character(Head(Eyes) Body) Background, got %s", tree)
+	}
+
+}
+
+func TestPlayFromFrame(t *testing.T) {
+
+	file := openExample(t)
+	player := file.CreatePlayerPlaying("idle")
+
+	entered := []string{}
+	player.OnTagEnter = func(tag Tag) { entered = append(entered, tag.Name) }
+
+	if err := player.PlayFromFrame("walk", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if player.CurrentTag.Name != "walk" || player.FrameIndex != 4 {
+		t.Errorf("expected walk to start at frame 4, got %q frame %d", player.CurrentTag.Name, player.FrameIndex)
+	}
+
+	if !strings.Contains(strings.Join(entered, ","), "walk") {
+		t.Errorf("expected OnTagEnter to be called for walk, got %v", entered)
+	}
+
+	player.Update(0.1)
+
+	if player.FrameIndex != 5 {
+		t.Errorf("expected playback to continue from the given frame, got frame %d", player.FrameIndex)
+	}
+
+	// Unlike Play(), the tag restarts from the given frame even though it's already playing.
+	player.PlayFromFrame("walk", 0)
+
+	if player.FrameIndex != 2 {
+		t.Errorf("expected walk to restart at frame 2, got frame %d", player.FrameIndex)
+	}
+
+	if err := player.PlayFromFrame("walk", 4); !errors.Is(err, ErrFrameOutOfRange) || player.FrameIndex != 2 {
+		t.Errorf("expected ErrFrameOutOfRange without changing the frame, got %v and frame %d", err, player.FrameIndex)
+	}
+
+}
+
+func TestPlayEx(t *testing.T) {
+
+	file := openExample(t)
+	player := file.CreatePlayer()
+
+	finished := 0
+	player.OnFinish = func() { finished++ }
+
+	// Play walk once, backward, starting from its second frame in play order (frame 4).
+	if err := player.PlayEx("walk", PlayOptions{Direction: PlayBackward, Loops: 1, StartFrame: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if player.FrameIndex != 4 {
+		t.Errorf("expected walk to start on frame 4, got frame %d", player.FrameIndex)
+	}
+
+	for i := 0; i < 5; i++ {
+		player.Update(0.1)
+	}
+
+	if player.FrameIndex != 2 || finished != 1 {
+		t.Errorf("expected walk to finish once on frame 2, got frame %d and %d finishes", player.FrameIndex, finished)
+	}
+
+	// Double speed, looping indefinitely.
+	player.PlayEx("walk", PlayOptions{Speed: 2})
+	player.Update(0.1)
+
+	if player.FrameIndex != 4 {
+		t.Errorf("expected double speed to advance two frames in 0.1 seconds, got frame %d", player.FrameIndex)
+	}
+
+	if player.Loops != 0 || player.PlaySpeed != 1 {
+		t.Errorf("expected PlayEx to leave the Player's Loops and PlaySpeed untouched, got %d and %f", player.Loops, player.PlaySpeed)
+	}
+
+	// Options only apply to the playthrough they were given for.
+	player.Play("idle")
+	player.Play("walk")
+	player.Update(0.1)
+
+	if player.FrameIndex != 3 {
+		t.Errorf("expected walk to play at normal speed after being replayed with Play(), got frame %d", player.FrameIndex)
+	}
+
+	if err := player.PlayEx("walk", PlayOptions{StartFrame: 4}); !errors.Is(err, ErrFrameOutOfRange) {
+		t.Errorf("expected ErrFrameOutOfRange for a start frame outside of the tag, got %v", err)
+	}
+
+}
+
+func TestFrameInfo(t *testing.T) {
+
+	file := openExample(t)
+
+	if inTag, tagLen, absolute := (&Player{File: file}).FrameInfo(); inTag != -1 || tagLen != -1 || absolute != -1 {
+		t.Errorf("expected -1s when no tag is playing, got %d, %d, %d", inTag, tagLen, absolute)
+	}
+
+	player := file.CreatePlayerPlaying("walk")
+	player.Update(0.1)
+
+	if inTag, tagLen, absolute := player.FrameInfo(); inTag != 1 || tagLen != 4 || absolute != 3 {
+		t.Errorf("expected frame 1/4 (abs 3), got frame %d/%d (abs %d)", inTag, tagLen, absolute)
+	}
+
+}
+
+func TestDurationScale(t *testing.T) {
+
+	defer func(scale float64) { DurationScale = scale }(DurationScale)
+	DurationScale = 1
+
+	file := readSheet(t, sheetJSON([]int{100, 250}, "", ""))
+
+	if file.Frames[0].Duration != 100 || file.Frames[1].Duration != 250 {
+		t.Errorf("expected durations to be kept in milliseconds, got %f and %f", file.Frames[0].Duration, file.Frames[1].Duration)
+	}
+
+}
+
+func TestFrameAtPlaybackTime(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 200, 100}, `
+		{"name": "forward", "from": 0, "to": 2, "direction": "forward"},
+		{"name": "bounce", "from": 0, "to": 2, "direction": "pingpong"}`, ""))
+
+	player := file.CreatePlayerPlaying("forward")
+
+	// A loop through forward takes 0.4 seconds; 1.35 seconds is 0.15 seconds into the fourth loop.
+	expected := map[float32]int{0: 0, 0.15: 1, 0.35: 2, 0.45: 0, 1.35: 1, 2.05: 0}
+
+	for time, frame := range expected {
+		if got := player.FrameAtPlaybackTime(time); got != frame {
+			t.Errorf("expected frame %d at %f seconds, got %d", frame, time, got)
+		}
+	}
+
+	// A loop through bounce (0, 1, 2, 1) takes 0.6 seconds.
+	player.Play("bounce")
+	expected = map[float32]int{0.35: 2, 0.45: 1, 0.65: 0, 1.05: 1, 1.35: 1}
+
+	for time, frame := range expected {
+		if got := player.FrameAtPlaybackTime(time); got != frame {
+			t.Errorf("expected ping-pong frame %d at %f seconds, got %d", frame, time, got)
+		}
+	}
+
+	if player.FrameIndex != 0 {
+		t.Errorf("expected FrameAtPlaybackTime not to change the Player's frame, got %d", player.FrameIndex)
+	}
+
+}
+
+func TestPlayingDefaultTag(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100}, `{"name": "", "from": 1, "to": 2, "direction": "forward"}`, ""))
+	player := file.CreatePlayer()
+
+	if player.PlayingDefaultTag() {
+		t.Error("expected a Player that isn't playing anything not to be playing the default tag")
+	}
+
+	player.Play("")
+
+	if !player.PlayingDefaultTag() {
+		t.Error("expected Play(\"\") to play the default tag")
+	}
+
+	// An authored tag that happens to have an empty name isn't the default tag, as it doesn't span the whole File.
+	player.CurrentTag = file.Tags[1]
+
+	if player.PlayingDefaultTag() {
+		t.Error("expected an empty-named tag that doesn't span all frames not to be the default tag")
+	}
+
+}
+
+func TestScaleDurations(t *testing.T) {
+
+	file := openExample(t)
+	file.ScaleDurations(2)
+
+	if file.Frames[0].Duration != 2 || file.Frames[2].Duration != 0.2 {
+		t.Errorf("expected durations to double, got %f and %f", file.Frames[0].Duration, file.Frames[2].Duration)
+	}
+
+	player := file.CreatePlayerPlaying("walk")
+	player.Update(0.1)
+
+	if player.FrameIndex != 2 {
+		t.Errorf("expected walk's frames to last twice as long, got frame %d after 0.1 seconds", player.FrameIndex)
+	}
+
+	player.Update(0.1)
+
+	if player.FrameIndex != 3 {
+		t.Errorf("expected walk to advance after 0.2 seconds, got frame %d", player.FrameIndex)
+	}
+
+	for _, factor := range []float32{0, -1} {
+		file.ScaleDurations(factor)
+		if file.Frames[2].Duration != 0.2 {
+			t.Errorf("expected a factor of %f to be ignored, got a duration of %f", factor, file.Frames[2].Duration)
+		}
+	}
+
+}
+
+func TestNinePatchSlices(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100}, "", `"slices": [
+		{"name": "panel", "color": "#0000ffff", "keys": [{"frame": 0, "bounds": {"x": 0, "y": 0, "w": 16, "h": 16}, "center": {"x": 4, "y": 4, "w": 8, "h": 6}}]},
+		{"name": "hitbox", "color": "#0000ffff", "keys": [{"frame": 0, "bounds": {"x": 2, "y": 2, "w": 4, "h": 4}}]}
+	]`))
+
+	panel, hitbox := file.Slices[0], file.Slices[1]
+
+	if !panel.IsNinePatch || panel.Keys[0].NinePatchCenter != image.Rect(4, 4, 12, 10) {
+		t.Errorf("expected panel to be a nine-patch with its center at (4, 4)-(12, 10), got %t and %v", panel.IsNinePatch, panel.Keys[0].NinePatchCenter)
+	}
+
+	if hitbox.IsNinePatch {
+		t.Error("expected a slice without a center not to be a nine-patch")
+	}
+
+}
+
+func TestLayersInDrawOrder(t *testing.T) {
+
+	// Aseprite exports layers from the bottom-most to the top-most.
+	file := readSheet(t, sheetJSON([]int{100}, "", `"layers": [
+		{"name": "Background", "opacity": 255, "blendMode": "normal"},
+		{"name": "Body", "opacity": 255, "blendMode": "normal"},
+		{"name": "Outline", "opacity": 255, "blendMode": "normal"}
+	]`))
+
+	names := func(layers []Layer) string {
+		out := []string{}
+		for _, layer := range layers {
+			out = append(out, layer.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	if order := names(file.LayersInDrawOrder()); order != "Background,Body,Outline" {
+		t.Errorf("expected layers to be drawn bottom to top (Background,Body,Outline), got %s", order)
+	}
+
+	if order := names(file.LayersTopToBottom()); order != "Outline,Body,Background" {
+		t.Errorf("expected layers from top to bottom (Outline,Body,Background), got %s", order)
+	}
+
+}
+
+func TestManualFrameJumpUVCoords(t *testing.T) {
+
+	player := openExample(t).CreatePlayerPlaying("walk")
+
+	// UV coordinates are computed from the current frame alone, so a manual jump shows up immediately, and the next Update()
+	// carries on from the new frame without any leftover state from before the jump.
+	player.SetFrameIndexInAnimation(3)
+
+	if u, _ := player.CurrentUVCoords(); u != 80.0/96 {
+		t.Errorf("expected the UV coordinates to follow the manual jump to frame 5 (u = %f), got %f", 80.0/96, u)
+	}
+
+	player.Update(0.1)
+
+	if u, _ := player.CurrentUVCoords(); u != 32.0/96 {
+		t.Errorf("expected the next Update() to wrap from frame 5 to frame 2 (u = %f), got %f", 32.0/96, u)
+	}
+
+}
+
+func TestFrameOpacity(t *testing.T) {
+
+	file := readSheet(t, `{"frames": {
+		"fade 0.aseprite": {"frame": {"x": 0, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100},
+		"fade 1.aseprite": {"frame": {"x": 16, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100, "opacity": 128}
+	}, "meta": {"image": "fade.png", "size": {"w": 32, "h": 16}, "frameTags": []}}`)
+
+	if file.Frames[0].Opacity != 255 {
+		t.Errorf("expected a frame without an opacity to be fully opaque, got %d", file.Frames[0].Opacity)
+	}
+
+	if file.Frames[1].Opacity != 128 {
+		t.Errorf("expected the frame's opacity to be 128, got %d", file.Frames[1].Opacity)
+	}
+
+}
+
+func TestLongestTag(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100, 100, 100, 100}, `
+		{"name": "short", "from": 0, "to": 0, "direction": "forward"},
+		{"name": "first", "from": 1, "to": 3, "direction": "forward"},
+		{"name": "second", "from": 3, "to": 5, "direction": "forward"}`, ""))
+
+	// The default tag is longer than any of them, but is excluded, and ties go to the first tag in the File.
+	if tag, ok := file.LongestTag(); !ok || tag.Name != "first" {
+		t.Errorf("expected the longest tag to be first, got %q (ok = %t)", tag.Name, ok)
+	}
+
+	if _, ok := readSheet(t, sheetJSON([]int{100}, "", "")).LongestTag(); ok {
+		t.Error("expected no longest tag in a File without named tags")
+	}
+
+}
+
+func TestOnFrameChangeTimed(t *testing.T) {
+
+	player := openExample(t).CreatePlayerPlaying("walk")
+
+	frames, offsets := []int{}, []float32{}
+	player.OnFrameChangeTimed = func(frameIndex int, timeIntoTick float32) {
+		frames = append(frames, frameIndex)
+		offsets = append(offsets, timeIntoTick)
+	}
+
+	// Walk's frames last 100ms each, so a 250ms tick crosses boundaries 100ms and 200ms into it.
+	player.Update(0.25)
+
+	if fmt.Sprint(frames) != "[3 4]" {
+		t.Fatalf("expected frame changes to frames 3 and 4, got %v", frames)
+	}
+
+	for i, expected := range []float32{0.1, 0.2} {
+		if math.Abs(float64(offsets[i]-expected)) > 0.0001 {
+			t.Errorf("expected frame %d to be reached %f seconds into the tick, got %f", frames[i], expected, offsets[i])
+		}
+	}
+
+}
+
+func TestTagMap(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100}, `
+		{"name": "idle", "from": 0, "to": 0, "direction": "forward"},
+		{"name": "walk", "from": 1, "to": 1, "direction": "forward"},
+		{"name": "walk", "from": 1, "to": 2, "direction": "forward"}`, ""))
+
+	tags := file.TagMap()
+
+	if len(tags) != 3 || tags["idle"].Start != 0 {
+		t.Errorf("expected the default, idle, and walk tags in the map, got %+v", tags)
+	}
+
+	if walk := tags["walk"]; walk.End != 2 {
+		t.Errorf("expected the last of the duplicate walk tags to win, got %d-%d", walk.Start, walk.End)
+	}
+
+}
+
+func TestRealtimeSpeed(t *testing.T) {
+
+	player := openExample(t).CreatePlayerPlaying("walk")
+
+	// Walk takes 0.4 seconds at normal speed, so playing it over 0.8 seconds needs half speed.
+	speed := player.RealtimeSpeed(0.8)
+
+	if math.Abs(float64(speed-0.5)) > 0.0001 {
+		t.Errorf("expected a speed of 0.5, got %f", speed)
+	}
+
+	if player.PlaySpeed != 1 {
+		t.Errorf("expected RealtimeSpeed not to change the PlaySpeed, got %f", player.PlaySpeed)
+	}
+
+	player.PlaySpeed = speed
+
+	elapsed := float32(0)
+	for !player.JustLooped() && elapsed < 10 {
+		player.Update(1.0 / 60)
+		elapsed += 1.0 / 60
+	}
+
+	if elapsed < 0.79 || elapsed > 0.81 {
+		t.Errorf("expected walk to loop after 0.8 seconds, took %f", elapsed)
+	}
+
+	if speed := player.RealtimeSpeed(0); speed != 1 {
+		t.Errorf("expected a target duration of 0 to return normal speed, got %f", speed)
+	}
+
+	// A tag whose frames all last no time can't be fit to any duration.
+	instant := readSheet(t, sheetJSON([]int{0, 0}, "", "")).CreatePlayerPlaying("")
+	instant.PlaySpeed = 1.5
+
+	if speed := instant.RealtimeSpeed(1); speed != 1.5 {
+		t.Errorf("expected a tag without any duration to keep the current speed of 1.5, got %f", speed)
+	}
+
+	defer func(parse bool) { ParseTagData = parse }(ParseTagData)
+	ParseTagData = true
+
+	// A tag speed of 0 is ignored, rather than giving an infinite PlaySpeed.
+	frozen := readSheet(t, sheetJSON([]int{100, 100}, `{"name": "frozen", "from": 0, "to": 1, "direction": "forward", "data": "speed:0"}`, "")).CreatePlayerPlaying("frozen")
+
+	if speed := frozen.RealtimeSpeed(0.4); math.Abs(float64(speed-0.5)) > 0.0001 {
+		t.Errorf("expected a tag speed of 0 to be ignored, giving a speed of 0.5, got %f", speed)
+	}
+
+}
+
+func TestNinePatchSchemas(t *testing.T) {
+
+	for _, schema := range []string{"center", "9slice"} {
+
+		file := readSheet(t, sheetJSON([]int{100}, "", fmt.Sprintf(`"slices": [
+			{"name": "panel", "color": "#0000ffff", "keys": [{"frame": 0, "bounds": {"x": 0, "y": 0, "w": 16, "h": 16}, %q: {"x": 3, "y": 2, "w": 10, "h": 12}}]}
+		]`, schema)))
+
+		panel := file.Slices[0]
+
+		if !panel.IsNinePatch || panel.Keys[0].NinePatchCenter != image.Rect(3, 2, 13, 14) {
+			t.Errorf("expected the %q schema to give a nine-patch center of (3, 2)-(13, 14), got %t and %v", schema, panel.IsNinePatch, panel.Keys[0].NinePatchCenter)
+		}
+
+	}
+
+}
+
+func TestOnDirectionChange(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100}, `{"name": "bounce", "from": 0, "to": 2, "direction": "pingpong"}`, ""))
+	player := file.CreatePlayerPlaying("bounce")
+
+	directions := []int{}
+	player.OnDirectionChange = func(newDirection int) { directions = append(directions, newDirection) }
+
+	// Each ping-pong cycle turns around once at each end; two full cycles (0, 1, 2, 1, 0, 1, 2, 1, 0) turn around at frame 0
+	// for the second time on the ninth update, when leaving it.
+	for i := 0; i < 9; i++ {
+		player.Update(0.1)
+	}
+
+	if fmt.Sprint(directions) != "[-1 1 -1 1]" {
+		t.Errorf("expected four direction changes alternating between -1 and 1, got %v", directions)
+	}
+
+	forward := file.CreatePlayerPlaying("")
+	forward.OnDirectionChange = func(int) { t.Error("expected no direction changes for a forward tag") }
+
+	for i := 0; i < 8; i++ {
+		forward.Update(0.1)
+	}
+
+}
+
+func TestAllFrameRects(t *testing.T) {
+
+	rects := openExample(t).AllFrameRects()
+
+	if len(rects) != 6 {
+		t.Fatalf("expected 6 rectangles, got %d", len(rects))
+	}
+
+	if rects[0] != image.Rect(0, 0, 16, 16) || rects[5] != image.Rect(80, 0, 96, 16) {
+		t.Errorf("expected the first and last rectangles to be (0, 0)-(16, 16) and (80, 0)-(96, 16), got %v and %v", rects[0], rects[5])
+	}
+
+	// Trimmed frames use their own size rather than the canvas size.
+	trimmed := readSheet(t, `{"frames": {
+		"trim 0.aseprite": {"frame": {"x": 0, "y": 0, "w": 10, "h": 12}, "sourceSize": {"w": 16, "h": 16}, "duration": 100},
+		"trim 1.aseprite": {"frame": {"x": 10, "y": 0, "w": 6, "h": 8}, "sourceSize": {"w": 16, "h": 16}, "duration": 100}
+	}, "meta": {"image": "trim.png", "size": {"w": 16, "h": 12}, "frameTags": []}}`)
+
+	if rects := trimmed.AllFrameRects(); rects[1] != image.Rect(10, 0, 16, 8) {
+		t.Errorf("expected the trimmed frame's rectangle to be (10, 0)-(16, 8), got %v", rects[1])
+	}
+
+	// CurrentFrameCoords agrees with the frame's rectangle.
+	player := trimmed.CreatePlayerPlaying("")
+	player.Update(0.1)
+
+	if x1, y1, x2, y2 := player.CurrentFrameCoords(); image.Rect(x1, y1, x2, y2) != image.Rect(10, 0, 16, 8) {
+		t.Errorf("expected the trimmed frame's coordinates to be (10, 0)-(16, 8), got (%d, %d)-(%d, %d)", x1, y1, x2, y2)
+	}
+
+}
+
+func TestPlaySubrange(t *testing.T) {
+
+	player := openExample(t).CreatePlayer()
+
+	loops := 0
+	player.OnLoop = func() { loops++ }
+
+	// Loop over walk's second and third frames (frames 3 and 4).
+	if err := player.PlaySubrange("walk", 1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	frames := []int{player.FrameIndex}
+	for i := 0; i < 5; i++ {
+		player.Update(0.1)
+		frames = append(frames, player.FrameIndex)
+	}
+
+	if fmt.Sprint(frames) != "[3 4 3 4 3 4]" {
+		t.Errorf("expected playback to stay within frames 3-4, got %v", frames)
+	}
+
+	if loops != 2 {
+		t.Errorf("expected OnLoop to fire each time the subrange wrapped (2 times), got %d", loops)
+	}
+
+	for _, subrange := range [][2]int{{-1, 1}, {2, 1}, {1, 4}} {
+		if err := player.PlaySubrange("walk", subrange[0], subrange[1]); !errors.Is(err, ErrFrameOutOfRange) {
+			t.Errorf("expected ErrFrameOutOfRange for subrange %v, got %v", subrange, err)
+		}
+	}
+
+}
+
+func TestFramesCrossedLastUpdate(t *testing.T) {
+
+	player := openExample(t).CreatePlayerPlaying("walk")
+
+	// 0.65 seconds crosses six of walk's 100ms frames, wrapping around once.
+	player.Update(0.65)
+
+	if crossed := fmt.Sprint(player.FramesCrossedLastUpdate()); crossed != "[3 4 5 2 3 4]" {
+		t.Errorf("expected frames [3 4 5 2 3 4] to be crossed, got %s", crossed)
+	}
+
+	player.Update(0.01)
+
+	if crossed := player.FramesCrossedLastUpdate(); len(crossed) != 0 {
+		t.Errorf("expected no frames to be crossed by a short update, got %v", crossed)
+	}
+
+}
+
+func TestBookmarks(t *testing.T) {
+
+	player := openExample(t).CreatePlayerPlaying("walk")
+	player.Update(0.15)
+
+	player.Bookmark("mid-step")
+
+	player.Play("idle")
+	player.Update(0.5)
+
+	if !player.GotoBookmark("mid-step") {
+		t.Fatal("expected the bookmark to exist")
+	}
+
+	if player.CurrentTag.Name != "walk" || player.FrameIndex != 3 {
+		t.Errorf("expected to return to walk's frame 3, got %q frame %d", player.CurrentTag.Name, player.FrameIndex)
+	}
+
+	// The bookmark was 50ms into frame 3, so another 50ms reaches frame 4.
+	player.Update(0.05)
+
+	if player.FrameIndex != 4 {
+		t.Errorf("expected the time spent on the frame to be restored, got frame %d", player.FrameIndex)
+	}
+
+	if player.GotoBookmark("missing") {
+		t.Error("expected a missing bookmark not to exist")
+	}
+
+}
+
+func TestImageURL(t *testing.T) {
+
+	expected := map[string]bool{
+		"https://example.com/sprites/hero.png?v=2": true,
+		"file:///C:/sprites/hero.png":              true,
+		"sprites/../hero.png":                      false,
+		"/absolute/path/hero.png":                  false,
+	}
+
+	for image, isURL := range expected {
+
+		file := readSheet(t, strings.Replace(sheetJSON([]int{100}, "", ""), `"image": "sheet.png"`, fmt.Sprintf(`"image": %q`, image), 1))
+
+		if file.ImageIsURL() != isURL {
+			t.Errorf("expected ImageIsURL() to be %t for %q", isURL, image)
+		}
+
+		if isURL && file.ImagePath != image {
+			t.Errorf("expected the URL %q to be left untouched, got %q", image, file.ImagePath)
+		} else if !isURL && file.ImagePath != filepath.Clean(image) {
+			t.Errorf("expected the path %q to be cleaned to %q, got %q", image, filepath.Clean(image), file.ImagePath)
+		}
+
+	}
+
+}
+
+func TestFingerprint(t *testing.T) {
+
+	json := sheetJSON([]int{100, 100}, `{"name": "idle", "from": 0, "to": 1, "direction": "forward"}`, "")
+
+	a, b := readSheet(t, json), readSheet(t, json)
+
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("expected Files read from identical data to have the same fingerprint")
+	}
+
+	changed := readSheet(t, strings.Replace(json, `"to": 1`, `"to": 0`, 1))
+
+	if a.Fingerprint() == changed.Fingerprint() {
+		t.Error("expected a changed tag to change the fingerprint")
+	}
+
+}
+
+func TestAdvanceRealtime(t *testing.T) {
+
+	file := openExample(t)
+
+	realtime, scaled := file.CreatePlayerPlaying("walk"), file.CreatePlayerPlaying("walk")
+	realtime.PlaySpeed, scaled.PlaySpeed = 3, 3
+
+	updates := 0
+	realtime.OnUpdate = func(dt float32) { updates++ }
+
+	// AdvanceRealtime ignores the PlaySpeed of 3, so it crosses one of walk's 100ms frames while Update() crosses four.
+	realtime.AdvanceRealtime(0.15)
+	scaled.Update(0.15)
+
+	if realtime.FrameIndex != 3 || scaled.FrameIndex != 2 {
+		t.Errorf("expected frames 3 and 2, got %d and %d", realtime.FrameIndex, scaled.FrameIndex)
+	}
+
+	realtime.AdvanceRealtime(0.1)
+	scaled.Update(0.1)
+
+	if realtime.FrameIndex != 4 || scaled.FrameIndex != 5 {
+		t.Errorf("expected frames 4 and 5, got %d and %d", realtime.FrameIndex, scaled.FrameIndex)
+	}
+
+	if realtime.PlaySpeed != 3 || updates != 2 {
+		t.Errorf("expected the PlaySpeed to be left alone and OnUpdate to be called each time, got %f and %d calls", realtime.PlaySpeed, updates)
+	}
+
+}
+
+func TestFrameMotion(t *testing.T) {
+
+	file := readSheet(t, `{"frames": {
+		"step 0.aseprite": {"frame": {"x": 0, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100},
+		"step 1.aseprite": {"frame": {"x": 16, "y": 0, "w": 16, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": 100, "position": {"x": 3, "y": -2}}
+	}, "meta": {"image": "step.png", "size": {"w": 32, "h": 16}, "frameTags": []}}`)
+
+	player := file.CreatePlayer()
+
+	if x, y := player.CurrentFrameMotion(); x != 0 || y != 0 {
+		t.Errorf("expected no motion when no tag is playing, got %d, %d", x, y)
+	}
+
+	player.Play("")
+
+	if x, y := player.CurrentFrameMotion(); x != 0 || y != 0 {
+		t.Errorf("expected a frame without position data to have no motion, got %d, %d", x, y)
+	}
+
+	player.Update(0.1)
+
+	if x, y := player.CurrentFrameMotion(); x != 3 || y != -2 {
+		t.Errorf("expected the frame's motion to be 3, -2, got %d, %d", x, y)
+	}
+
+}
+
+func TestHasNamedTags(t *testing.T) {
+
+	if !openExample(t).HasNamedTags() {
+		t.Error("expected the example File to have named tags")
+	}
+
+	if readSheet(t, sheetJSON([]int{100, 100}, "", "")).HasNamedTags() {
+		t.Error("expected a File with only the default tag not to have named tags")
+	}
+
+}
+
+func TestEaseStop(t *testing.T) {
+
+	file := openExample(t)
+
+	for _, advance := range []func(*Player, float32){(*Player).Update, (*Player).AdvanceRealtime} {
+
+		player := file.CreatePlayerPlaying("walk")
+		player.EaseStop(0.5)
+
+		if !player.IsEasing() {
+			t.Fatal("expected the Player to be easing")
+		}
+
+		advance(player, 0.25)
+
+		if math.Abs(float64(player.PlaySpeed-0.5)) > 0.0001 {
+			t.Errorf("expected the PlaySpeed to be halfway to a stop (0.5), got %f", player.PlaySpeed)
+		}
+
+		advance(player, 0.25)
+
+		if player.IsEasing() || !player.IsPaused() {
+			t.Errorf("expected the Player to stop easing and pause, got easing = %t, paused = %t", player.IsEasing(), player.IsPaused())
+		}
+
+		frame := player.FrameIndex
+		advance(player, 1)
+
+		if player.FrameIndex != frame {
+			t.Errorf("expected playback to hold on frame %d, got frame %d", frame, player.FrameIndex)
+		}
+
+		if player.PlaySpeed != 1 {
+			t.Errorf("expected the PlaySpeed to be restored once stopped, got %f", player.PlaySpeed)
+		}
+
+	}
+
+}
+
+func TestTagIndexBase(t *testing.T) {
+
+	defer func(base int) { TagIndexBase = base }(TagIndexBase)
+	TagIndexBase = 1
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100, 100}, `
+		{"name": "idle", "from": 1, "to": 2, "direction": "forward"},
+		{"name": "walk", "from": 3, "to": 4, "direction": "forward"}`, ""))
+
+	idle, _ := file.TagByName("idle")
+	walk, _ := file.TagByName("walk")
+
+	if idle.Start != 0 || idle.End != 1 || walk.Start != 2 || walk.End != 3 {
+		t.Errorf("expected 1-based tags to be read as idle 0-1 and walk 2-3, got idle %d-%d and walk %d-%d", idle.Start, idle.End, walk.Start, walk.End)
+	}
+
+	if tag, _ := file.TagByName(""); tag.Start != 0 || tag.End != 3 {
+		t.Errorf("expected the default tag to be unaffected, got %d-%d", tag.Start, tag.End)
+	}
+
+}
+
+func TestCurrentFrameCenter(t *testing.T) {
+
+	file := openExample(t)
+
+	if x, y := (&Player{File: file}).CurrentFrameCenter(); x != -1 || y != -1 {
+		t.Errorf("expected -1, -1 when no tag is playing, got %f, %f", x, y)
+	}
+
+	player := file.CreatePlayerPlaying("walk")
+
+	// Frame 2 spans (32, 0)-(48, 16) on the 96x16 spritesheet.
+	if x, y := player.CurrentFrameCenter(); x != 40 || y != 8 {
+		t.Errorf("expected the center to be 40, 8, got %f, %f", x, y)
+	}
+
+	if u, v := player.CurrentUVCenter(); u != 40.0/96 || v != 0.5 {
+		t.Errorf("expected the UV center to be %f, 0.5, got %f, %f", 40.0/96, u, v)
+	}
+
+}
+
+func TestOpenDir(t *testing.T) {
+
+	example, err := os.ReadFile(filepath.Join("example", "16x16Deliveryman.json"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := fstest.MapFS{
+		"sprites/hero.json":         {Data: example},
+		"sprites/coin.json":         {Data: []byte(sheetJSON([]int{100, 100}, "", ""))},
+		"sprites/settings.json":     {Data: []byte(`{"volume": 0.5}`)},
+		"sprites/notes.txt":         {Data: []byte("not a sprite")},
+		"sprites/nested/enemy.json": {Data: example},
+	}
+
+	files, err := OpenDir("sprites", fsys)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 || files["hero"] == nil || files["coin"] == nil {
+		t.Fatalf("expected only hero and coin to be loaded, got %v", files)
+	}
+
+	if files["hero"].Path != "sprites/hero.json" || len(files["coin"].Frames) != 2 {
+		t.Errorf("expected the files to be read correctly, got path %q and %d frames", files["hero"].Path, len(files["coin"].Frames))
+	}
+
+	if _, err := OpenDir("missing", fsys); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+
+}
+
+func TestSetOnFinishOnce(t *testing.T) {
+
+	player := openExample(t).CreatePlayer()
+	player.Loops = 1
+
+	calls := 0
+	player.SetOnFinishOnce(func() { calls++ })
+
+	player.Play("walk")
+	for i := 0; i < 5; i++ {
+		player.Update(0.1)
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected the callback to fire once the tag finished, got %d calls", calls)
+	}
+
+	// Playing and finishing the tag again shouldn't call the cleared callback.
+	player.Play("walk")
+	for i := 0; i < 5; i++ {
+		player.Update(0.1)
+	}
+
+	if calls != 1 || !player.Finished() {
+		t.Errorf("expected the callback to be cleared after firing, got %d calls", calls)
+	}
+
+}
+
+func TestFrameNameTagBounds(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100, 100}, `
+		{"name": "walk", "from": "sheet 1", "to": "sheet 3.aseprite", "direction": "forward"}`, ""))
+
+	if walk, ok := file.TagByName("walk"); !ok || walk.Start != 1 || walk.End != 3 {
+		t.Errorf("expected the name-referenced walk tag to span frames 1-3, got %d-%d (ok = %t)", walk.Start, walk.End, ok)
+	}
+
+	_, err := ReadString(sheetJSON([]int{100, 100}, `{"name": "jump", "from": "sheet 0", "to": "jump 9", "direction": "forward"}`, ""))
+
+	if !errors.Is(err, ErrUnknownFrameName) || !strings.Contains(err.Error(), `"jump"`) {
+		t.Errorf("expected an error naming the jump tag and wrapping ErrUnknownFrameName, got %v", err)
+	}
+
+}
+
+func TestIsLooping(t *testing.T) {
+
+	file := openExample(t)
+
+	if (&Player{File: file}).IsLooping() {
+		t.Error("expected a Player that isn't playing anything not to be looping")
+	}
+
+	looping := file.CreatePlayerPlaying("walk")
+
+	if !looping.IsLooping() {
+		t.Error("expected a tag with Loops = 0 to be looping")
+	}
+
+	repeating := file.CreatePlayerPlaying("walk")
+	repeating.Loops = 2
+
+	if !repeating.IsLooping() {
+		t.Error("expected the first of two loops to be looping")
+	}
+
+	for i := 0; i < 4; i++ {
+		repeating.Update(0.1)
+	}
+
+	if repeating.IsLooping() {
+		t.Error("expected the last of two loops not to be looping")
+	}
+
+	once := file.CreatePlayer()
+	once.PlayEx("walk", PlayOptions{Loops: 1})
+
+	if once.IsLooping() {
+		t.Error("expected a tag played once not to be looping")
+	}
+
+}
+
+// marginSheet returns a 32x16 spritesheet image for a File read with sheetJSON([]int{100, 100}, ...): the first frame has
+// opaque content spanning (2, 3)-(10, 12) within it, and the second frame is fully transparent.
+func marginSheet() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 32, 16))
+	for y := 3; y < 12; y++ {
+		for x := 2; x < 10; x++ {
+			img.Set(x, y, color.White)
+		}
+	}
+	return img
+}
+
+func TestContentBounds(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100}, "", ""))
+	img := marginSheet()
+
+	if bounds := file.ContentBounds(img, 0); bounds != image.Rect(2, 3, 10, 12) {
+		t.Errorf("expected the content bounds to be (2, 3)-(10, 12), got %v", bounds)
+	}
+
+	if bounds := file.ContentBounds(img, 1); !bounds.Empty() {
+		t.Errorf("expected a fully transparent frame to have empty bounds, got %v", bounds)
+	}
+
+	if bounds := file.ContentBounds(img, 2); !bounds.Empty() {
+		t.Errorf("expected an out-of-range frame to have empty bounds, got %v", bounds)
+	}
+
+	// A File is shared by Players, so ContentBounds can be called from several goroutines at once (run with -race).
+	shared := readSheet(t, sheetJSON([]int{100, 100}, "", ""))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for frame := 0; frame < 2; frame++ {
+				shared.ContentBounds(img, frame)
+			}
+		}()
+	}
+	wg.Wait()
+
+}
+
+func TestKeyDeltas(t *testing.T) {
+
+	slice := Slice{Keys: []SliceKey{
+		{Frame: 0, X: 0, Y: 0},
+		{Frame: 1, X: 2, Y: 2},
+		{Frame: 2, X: 4, Y: 4},
+		{Frame: 4, X: 7, Y: 7},
+	}}
+
+	// Frame 3 has no key, so the key from frame 2 persists, and the slice only moves again on frame 4.
+	if deltas := fmt.Sprint(slice.KeyDeltas()); deltas != "[(2,2) (2,2) (0,0) (3,3)]" {
+		t.Errorf("expected deltas [(2,2) (2,2) (0,0) (3,3)], got %s", deltas)
+	}
+
+	if deltas := (Slice{Keys: slice.Keys[:1]}).KeyDeltas(); len(deltas) != 0 {
+		t.Errorf("expected no deltas for a single key, got %v", deltas)
+	}
+
+}
+
+func TestClampFrameToTag(t *testing.T) {
+
+	file := openExample(t)
+
+	for _, clamp := range []bool{false, true} {
+
+		player := file.CreatePlayerPlaying("walk")
+		player.ClampFrameToTag = clamp
+		player.FrameIndex = 0
+
+		player.Update(0.01)
+
+		if clamp && player.FrameIndex != 2 {
+			t.Errorf("expected the out-of-tag frame to be clamped to walk's start (2), got %d", player.FrameIndex)
+		} else if !clamp && player.FrameIndex != 0 {
+			t.Errorf("expected the out-of-tag frame to be left alone without clamping, got %d", player.FrameIndex)
+		}
+
+	}
+
+}
+
+func TestTagDirectionKeys(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100}, `
+		{"name": "a", "from": 0, "to": 1, "aniDir": "reverse"},
+		{"name": "b", "from": 0, "to": 1, "animationDirection": "pingpong"},
+		{"name": "c", "from": 0, "to": 1, "direction": "reverse", "aniDir": "pingpong"},
+		{"name": "d", "from": 0, "to": 1}`, ""))
+
+	expected := map[string]string{"a": PlayBackward, "b": PlayPingPong, "c": PlayBackward, "d": PlayForward}
+
+	for name, direction := range expected {
+		if tag, _ := file.TagByName(name); tag.Direction != direction {
+			t.Errorf("expected tag %q to play %q, got %q", name, direction, tag.Direction)
+		}
+	}
+
+}
+
+func TestTimeUntilFrame(t *testing.T) {
+
+	player := openExample(t).CreatePlayerPlaying("walk")
+
+	// Halfway through walk's second frame (frame 3).
+	player.Update(0.15)
+
+	if wait := player.TimeUntilFrame(3, false); math.Abs(float64(wait-0.15)) > 0.0001 {
+		t.Errorf("expected walk's last frame to be 0.15 seconds away, got %f", wait)
+	}
+
+	if wait := player.TimeUntilFrame(1, false); wait != 0 {
+		t.Errorf("expected the current frame to be 0 seconds away, got %f", wait)
+	}
+
+	if wait := player.TimeUntilFrame(0, false); wait != -1 {
+		t.Errorf("expected an earlier frame to be unreachable without wrapping, got %f", wait)
+	}
+
+	if wait := player.TimeUntilFrame(0, true); math.Abs(float64(wait-0.25)) > 0.0001 {
+		t.Errorf("expected walk's first frame to be 0.25 seconds away when wrapping, got %f", wait)
+	}
+
+	if wait := player.TimeUntilFrame(4, true); wait != -1 {
+		t.Errorf("expected a frame outside of the tag to be unreachable, got %f", wait)
+	}
+
+}
+
+func TestEventChannel(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100}, `
+		{"name": "a", "from": 0, "to": 1, "direction": "forward"},
+		{"name": "b", "from": 2, "to": 2, "direction": "forward"}`, ""))
+
+	player := file.CreatePlayerPlaying("")
+	events := player.EventChannel()
+
+	// Play through the whole File once: 0 -> 1 -> 2 (exiting a and entering b) -> 0 (looping, exiting b and entering a).
+	for i := 0; i < 3; i++ {
+		player.Update(0.1)
+	}
+
+	names := map[EventType]string{EventFrameChange: "change", EventLoop: "loop", EventTagEnter: "enter", EventTagExit: "exit"}
+	received := []string{}
+
+	for len(events) > 0 {
+		event := <-events
+		received = append(received, fmt.Sprintf("%s:%d:%s", names[event.Type], event.FrameIndex, event.Tag.Name))
+	}
+
+	if order := strings.Join(received, " "); order != "change:1: change:2: exit:2:a enter:2:b loop:0: change:0: exit:0:b enter:0:a" {
+		t.Errorf("unexpected events: %s", order)
+	}
+
+	// Events beyond the buffer's capacity are dropped rather than blocking Update().
+	for i := 0; i < EventBufferSize*2; i++ {
+		player.Update(0.1)
+	}
+
+	if len(events) != EventBufferSize {
+		t.Errorf("expected the channel to be full with %d events, got %d", EventBufferSize, len(events))
+	}
+
+}
+
+func TestEstimatedBytes(t *testing.T) {
+
+	rgba := openExample(t)
+
+	// The example spritesheet is 96x16 and exported as RGBA8888.
+	if bytes := rgba.EstimatedImageBytes(); bytes != 96*16*4 {
+		t.Errorf("expected %d bytes, got %d", 96*16*4, bytes)
+	}
+
+	if bytes := rgba.EstimatedFormatBytes(); bytes != 96*16*4 {
+		t.Errorf("expected %d bytes for an RGBA file, got %d", 96*16*4, bytes)
+	}
+
+	indexed := readSheet(t, strings.Replace(sheetJSON([]int{100, 100}, "", ""), `"format": "RGBA8888"`, `"format": "I8"`, 1))
+
+	if bytes := indexed.EstimatedFormatBytes(); bytes != 32*16 {
+		t.Errorf("expected %d bytes for an indexed file, got %d", 32*16, bytes)
+	}
+
+	if bytes := indexed.EstimatedImageBytes(); bytes != 32*16*4 {
+		t.Errorf("expected EstimatedImageBytes to assume RGBA (%d bytes), got %d", 32*16*4, bytes)
+	}
+
+}
+
+func TestPlayReversed(t *testing.T) {
+
+	player := openExample(t).CreatePlayerPlaying("idle")
+
+	entered := []string{}
+	player.OnTagEnter = func(tag Tag) { entered = append(entered, tag.Name) }
+
+	if err := player.PlayReversed("walk"); err != nil {
+		t.Fatal(err)
+	}
+
+	if player.FrameIndex != 5 {
+		t.Errorf("expected walk to start on its End frame (5), got frame %d", player.FrameIndex)
+	}
+
+	if !strings.Contains(strings.Join(entered, ","), "walk") {
+		t.Errorf("expected OnTagEnter to be called for walk, got %v", entered)
+	}
+
+	player.Update(0.1)
+
+	if player.FrameIndex != 4 {
+		t.Errorf("expected walk to play backward to frame 4, got frame %d", player.FrameIndex)
+	}
+
+	if err := player.PlayReversed("missing"); !errors.Is(err, ErrNoTagByName) {
+		t.Errorf("expected ErrNoTagByName, got %v", err)
+	}
+
+}
+
+func TestReadMulti(t *testing.T) {
+
+	hero := sheetJSON([]int{100, 100}, `{"name": "walk", "from": 0, "to": 1, "direction": "forward"}`, "")
+	coin := strings.Replace(sheetJSON([]int{100, 100, 100}, "", ""), "sheet.png", "coin.png", 1)
+
+	files, err := ReadMulti([]byte(fmt.Sprintf(`{"hero": %s, "coin": %s}`, hero, coin)))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 || len(files["hero"].Frames) != 2 || len(files["coin"].Frames) != 3 {
+		t.Fatalf("expected hero with 2 frames and coin with 3 frames, got %v", files)
+	}
+
+	if _, ok := files["hero"].TagByName("walk"); !ok {
+		t.Error("expected hero's walk tag to be read")
+	}
+
+	single, err := ReadMulti([]byte(coin))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(single) != 1 || single["coin"] == nil {
+		t.Errorf("expected a single-sprite file to be keyed by its image name (coin), got %v", single)
+	}
+
+	if _, err := ReadMulti([]byte(`{"settings": {"volume": 1}}`)); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("expected ErrInvalidJSON for data without any sprites, got %v", err)
+	}
+
+}
+
+func TestFrameRectAtTime(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 200, 100}, `
+		{"name": "forward", "from": 0, "to": 2, "direction": "forward"},
+		{"name": "backward", "from": 0, "to": 2, "direction": "reverse"},
+		{"name": "bounce", "from": 0, "to": 2, "direction": "pingpong"}`, ""))
+
+	cases := []struct {
+		tag   string
+		time  float32
+		frame int
+	}{
+		{"forward", 0.05, 0},
+		{"forward", 0.25, 1},
+		{"forward", 0.85, 0}, // 0.05 seconds into the third loop
+		{"backward", 0.05, 2},
+		{"backward", 0.15, 1},
+		{"backward", 0.35, 0},
+		{"bounce", 0.35, 2},
+		{"bounce", 0.45, 1},
+		{"bounce", 0.65, 0},
+	}
+
+	for _, c := range cases {
+		if rect, ok := file.FrameRectAtTime(c.tag, c.time); !ok || rect != file.frameRect(c.frame) {
+			t.Errorf("expected tag %q to show frame %d at %f seconds, got %v (ok = %t)", c.tag, c.frame, c.time, rect, ok)
+		}
+	}
+
+	if _, ok := file.FrameRectAtTime("missing", 0); ok {
+		t.Error("expected a missing tag not to return a rectangle")
+	}
+
+}
+
+func TestSliceDataMap(t *testing.T) {
+
+	cases := map[string]string{
+		"type:hurtbox; damage:2":           "map[damage:2 type:hurtbox]",
+		`{"type": "hurtbox", "damage": 2}`: "map[damage:2 type:hurtbox]",
+		`{"type": "hurtbox", "damage": 2`:  "map[]",
+		"":                                 "map[]",
+	}
+
+	for data, expected := range cases {
+		if got := fmt.Sprint(Slice{Data: data}.DataMap()); got != expected {
+			t.Errorf("expected data %q to parse to %s, got %s", data, expected, got)
+		}
+	}
+
+}
+
+func TestAutoPauseOnFinish(t *testing.T) {
+
+	player := openExample(t).CreatePlayer()
+	player.Loops = 1
+	player.AutoPauseOnFinish = true
+
+	player.Play("walk")
+	for i := 0; i < 4; i++ {
+		player.Update(0.1)
+	}
+
+	if !player.Finished() || !player.IsPaused() {
+		t.Fatalf("expected the Player to pause itself once walk finished, got finished = %t, paused = %t", player.Finished(), player.IsPaused())
+	}
+
+	frame := player.FrameIndex
+	player.Update(1)
+
+	if player.FrameIndex != frame || player.JustChangedFrame() {
+		t.Errorf("expected Update to be a no-op after finishing, got frame %d", player.FrameIndex)
+	}
+
+	// Playing another tag resumes the Player.
+	player.Play("idle")
+
+	if player.IsPaused() {
+		t.Error("expected playing a new tag to resume the Player")
+	}
+
+}
+
+func TestEffectiveSpeed(t *testing.T) {
+
+	defer func(parse bool) { ParseTagData = parse }(ParseTagData)
+	ParseTagData = true
+
+	file := readSheet(t, sheetJSON([]int{100, 100}, `{"name": "dash", "from": 0, "to": 1, "direction": "forward", "data": "speed:2"}`, ""))
+	player := file.CreatePlayer()
+
+	if speed := player.EffectiveSpeed(); speed != 0 {
+		t.Errorf("expected a speed of 0 when no tag is playing, got %f", speed)
+	}
+
+	player.PlayEx("dash", PlayOptions{Speed: 1.5})
+	player.PlaySpeed = 2
+
+	// PlaySpeed (2) * the tag's speed (2) * the PlayEx speed (1.5).
+	if speed := player.EffectiveSpeed(); speed != 6 {
+		t.Errorf("expected an effective speed of 6, got %f", speed)
+	}
+
+	player.EaseStop(1)
+	player.Update(0.5)
+
+	if speed := player.EffectiveSpeed(); math.Abs(float64(speed-3)) > 0.0001 {
+		t.Errorf("expected an effective speed of 3 halfway through easing to a stop, got %f", speed)
+	}
+
+	player.Pause()
+
+	if speed := player.EffectiveSpeed(); speed != 0 {
+		t.Errorf("expected a speed of 0 while paused, got %f", speed)
+	}
+
+}
+
+func TestSkipEmptyFrames(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100}, "", ""))
+
+	// Frames 0 and 2 have content; frame 1 is fully transparent.
+	img := image.NewNRGBA(image.Rect(0, 0, 48, 16))
+	img.Set(4, 4, color.White)
+	img.Set(36, 4, color.White)
+
+	player := file.CreatePlayer()
+	player.SkipEmptyFrames = true
+	player.SetImage(img)
+	player.Play("")
+	player.Update(0.1)
+
+	if player.FrameIndex != 2 {
+		t.Errorf("expected the transparent frame to be skipped, landing on frame 2, got frame %d", player.FrameIndex)
+	}
+
+	// Skipped frames don't fire frame events or count as played, and timing queries leave them out.
+	player.PlayFromFrame("", 0)
+	player.ResetCounters()
+
+	changes, reached := []int{}, false
+	player.OnFrameChange = func() { changes = append(changes, player.FrameIndex) }
+	player.OnReachFrame(1, func() { reached = true })
+
+	if remaining := player.RemainingTime(); math.Abs(float64(remaining-0.2)) > 0.0001 {
+		t.Errorf("expected 0.2 seconds remaining without the transparent frame, got %f", remaining)
+	}
+
+	if until := player.TimeUntilFrame(2, false); math.Abs(float64(until-0.1)) > 0.0001 {
+		t.Errorf("expected frame 2 to be reached in 0.1 seconds, got %f", until)
+	}
+
+	if until := player.TimeUntilFrame(1, true); until != -1 {
+		t.Errorf("expected the transparent frame never to be reached, got %f", until)
+	}
+
+	player.Update(0.1)
+
+	if fmt.Sprint(changes) != "[2]" || reached || player.TotalFramesPlayed() != 1 || player.PrevFrameIndex != 0 {
+		t.Errorf("expected a single frame change from 0 to 2, got changes %v (frame 1 reached: %t, frames played: %d, previous frame: %d)",
+			changes, reached, player.TotalFramesPlayed(), player.PrevFrameIndex)
+	}
+
+	player.OnFrameChange = nil
+	player.ClearAllReachFrames()
+
+	// Setting a new image invalidates the cached scan, so frame 1 is no longer skipped.
+	full := image.NewNRGBA(image.Rect(0, 0, 48, 16))
+	for x := 0; x < 48; x++ {
+		full.Set(x, 4, color.White)
+	}
+
+	player.SetImage(full)
+	player.PlayFromFrame("", 0)
+	player.Update(0.1)
+
+	if player.FrameIndex != 1 {
+		t.Errorf("expected frame 1 to play once it has content, got frame %d", player.FrameIndex)
+	}
+
+	// Players sharing a File must be able to update concurrently (run with -race).
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		other := file.CreatePlayer()
+		other.SkipEmptyFrames = true
+		other.SetImage(img)
+		other.Play("")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				other.Update(0.05)
+			}
+		}()
+	}
+	wg.Wait()
+
+}
+
+func TestCurrentImage(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100}, "", ""))
+	img := marginSheet()
+	player := file.CreatePlayer()
+
+	if player.CurrentImage() != nil {
+		t.Error("expected no current image before an image is set")
+	}
+
+	player.SetImage(img)
+
+	if player.Image() != img {
+		t.Error("expected Image() to return the image given to SetImage()")
+	}
+
+	if player.CurrentImage() != nil {
+		t.Error("expected no current image while no tag is playing")
+	}
+
+	player.Play("")
+	player.Update(0.1)
+
+	current := player.CurrentImage()
+
+	if current == nil {
+		t.Fatal("expected a current image once a tag is playing")
+	}
+
+	if bounds := current.Bounds(); bounds != image.Rect(16, 0, 32, 16) {
+		t.Errorf("expected the current image to cover frame 1 at (16, 0)-(32, 16), got %v", bounds)
+	}
+
+}
+
+func TestTagBoundaryRects(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100}, `{"name": "fwd", "from": 0, "to": 2, "direction": "forward"},
+		{"name": "rev", "from": 0, "to": 2, "direction": "reverse"}`, ""))
+	player := file.CreatePlayer()
+
+	if _, _, ok := player.TagBoundaryRects(); ok {
+		t.Error("expected TagBoundaryRects() to report no tag playing")
+	}
+
+	player.Play("fwd")
+
+	first, last, ok := player.TagBoundaryRects()
+	if !ok || first != image.Rect(0, 0, 16, 16) || last != image.Rect(32, 0, 48, 16) {
+		t.Errorf("expected a forward tag to run from frame 0 to frame 2, got %v to %v (ok: %t)", first, last, ok)
+	}
+
+	player.Play("rev")
+
+	first, last, ok = player.TagBoundaryRects()
+	if !ok || first != image.Rect(32, 0, 48, 16) || last != image.Rect(0, 0, 16, 16) {
+		t.Errorf("expected a reverse tag to run from frame 2 to frame 0, got %v to %v (ok: %t)", first, last, ok)
+	}
+
+}
+
+func TestStringSize(t *testing.T) {
+
+	json := strings.Replace(sheetJSON([]int{100, 100}, "", ""), `"size": {"w": 32, "h": 16}`, `"size": {"w": "32", "h": "16"}`, 1)
+	if !strings.Contains(json, `"w": "32"`) {
+		t.Fatal("expected the test data to have a string-typed size")
+	}
+
+	file := readSheet(t, json)
+
+	if file.Width != 32 || file.Height != 16 {
+		t.Errorf("expected a string-typed size to read as 32x16, got %dx%d", file.Width, file.Height)
+	}
+
+	player := file.CreatePlayer()
+	player.Play("")
+
+	if u0, v0, u1, v1 := player.CurrentUVRectFlipped(false, false); u0 != 0 || v0 != 0 || u1 != 0.5 || v1 != 1 {
+		t.Errorf("expected UV coordinates of (0, 0, 0.5, 1), got (%f, %f, %f, %f)", u0, v0, u1, v1)
+	}
+
+}
+
+func TestTotalFramesPlayed(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100}, `{"name": "a", "from": 0, "to": 1, "direction": "forward"}`, ""))
+	player := file.CreatePlayer()
+	player.Play("")
+
+	for i := 0; i < 4; i++ {
+		player.Update(0.1)
+	}
+
+	if count := player.TotalFramesPlayed(); count != 4 {
+		t.Errorf("expected 4 frames played, got %d", count)
+	}
+
+	player.Play("a")
+
+	if count := player.TotalFramesPlayed(); count != 4 {
+		t.Errorf("expected playing a new tag to keep the count at 4, got %d", count)
+	}
+
+	player.Update(0.05)
+	player.Update(0.1)
+
+	if count := player.TotalFramesPlayed(); count != 5 {
+		t.Errorf("expected 5 frames played, got %d", count)
+	}
+
+	player.ResetCounters()
+
+	if count := player.TotalFramesPlayed(); count != 0 {
+		t.Errorf("expected ResetCounters() to reset the count to 0, got %d", count)
+	}
+
+}
+
+func TestLoadTagsFromReader(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100, 100}, `{"name": "idle", "from": 0, "to": 0, "direction": "forward"}`, ""))
+
+	csv := "name,start,end,direction\n# a comment\nidle,0,1,pingpong\nrun,2,3\n"
+
+	if err := file.LoadTagsFromReader(strings.NewReader(csv)); err != nil {
+		t.Fatal(err)
+	}
+
+	idle, _ := file.TagByName("idle")
+	if idle.Start != 0 || idle.End != 1 || idle.Direction != PlayPingPong {
+		t.Errorf("expected idle to be replaced with a ping-pong tag spanning 0-1, got %d-%d %s", idle.Start, idle.End, idle.Direction)
+	}
+
+	run, ok := file.TagByName("run")
+	if !ok || run.Start != 2 || run.End != 3 || run.Direction != PlayForward {
+		t.Errorf("expected run to be added as a forward tag spanning 2-3, got %d-%d %s", run.Start, run.End, run.Direction)
+	}
+
+	if err := file.LoadTagsFromReader(strings.NewReader("jump\t1\t2\treverse\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if jump, ok := file.TagByName("jump"); !ok || jump.Start != 1 || jump.End != 2 || jump.Direction != PlayBackward {
+		t.Errorf("expected tab-separated data to add a reverse tag spanning 1-2, got %d-%d %s", jump.Start, jump.End, jump.Direction)
+	}
+
+	tagCount := len(file.Tags)
+
+	if err := file.LoadTagsFromReader(strings.NewReader("fall,2,4\n")); !errors.Is(err, ErrFrameOutOfRange) {
+		t.Errorf("expected ErrFrameOutOfRange for a tag past the last frame, got %v", err)
+	}
+
+	if err := file.LoadTagsFromReader(strings.NewReader("ok,0,1\nback,3,2\n")); !errors.Is(err, ErrFrameOutOfRange) {
+		t.Errorf("expected ErrFrameOutOfRange for a tag ending before it starts, got %v", err)
+	}
+
+	malformed := []string{"fall,2\n", "fall,a,2\n", "fall,0,b\n", "fall,0,1,sideways\n", "fall,0,1,forward,extra\n", "\"fall,0,1\n"}
+
+	for _, data := range malformed {
+		if err := file.LoadTagsFromReader(strings.NewReader(data)); !errors.Is(err, ErrInvalidTagRecord) {
+			t.Errorf("expected ErrInvalidTagRecord for the malformed data %q, got %v", data, err)
+		}
+	}
+
+	if len(file.Tags) != tagCount || file.HasTag("ok") {
+		t.Error("expected a failed load to leave the File's tags unchanged")
+	}
+
+}
+
+func TestCurrentUVRectFlipped(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100}, "", ""))
+	player := file.CreatePlayer()
+
+	if u0, v0, u1, v1 := player.CurrentUVRectFlipped(false, false); u0 != -1 || v0 != -1 || u1 != -1 || v1 != -1 {
+		t.Errorf("expected all -1's when no tag is playing, got (%f, %f, %f, %f)", u0, v0, u1, v1)
+	}
+
+	player.Play("")
+	player.Update(0.1)
+
+	tests := []struct {
+		flipH, flipV   bool
+		u0, v0, u1, v1 float64
+	}{
+		{false, false, 0.5, 0, 1, 1},
+		{true, false, 1, 0, 0.5, 1},
+		{false, true, 0.5, 1, 1, 0},
+		{true, true, 1, 1, 0.5, 0},
+	}
+
+	for _, test := range tests {
+		u0, v0, u1, v1 := player.CurrentUVRectFlipped(test.flipH, test.flipV)
+		if u0 != test.u0 || v0 != test.v0 || u1 != test.u1 || v1 != test.v1 {
+			t.Errorf("flipH %t, flipV %t: expected (%f, %f, %f, %f), got (%f, %f, %f, %f)",
+				test.flipH, test.flipV, test.u0, test.v0, test.u1, test.v1, u0, v0, u1, v1)
+		}
+	}
+
+}
+
+func TestFallbackTag(t *testing.T) {
+
+	file := openExample(t)
+	player := file.CreatePlayer()
+	player.Play("walk")
+
+	if err := player.Play("run"); !errors.Is(err, ErrNoTagByName) || player.CurrentTag.Name != "walk" {
+		t.Errorf("expected an unknown tag to keep walk playing without a fallback, got %q (error %v)", player.CurrentTag.Name, err)
+	}
+
+	player.FallbackTag = "idle"
+
+	if err := player.Play("run"); !errors.Is(err, ErrNoTagByName) {
+		t.Errorf("expected ErrNoTagByName even when falling back, got %v", err)
+	}
+
+	if player.CurrentTag.Name != "idle" {
+		t.Errorf("expected the fallback tag idle to play, got %q", player.CurrentTag.Name)
+	}
+
+	player.Play("walk")
+	player.FallbackTag = "missing"
+
+	if player.Play("run"); player.CurrentTag.Name != "walk" {
+		t.Errorf("expected a missing fallback tag to keep walk playing, got %q", player.CurrentTag.Name)
+	}
+
+}
+
+func TestThumbnailFrame(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100, 100, 100, 100, 100}, `{"name": "odd", "from": 1, "to": 3, "direction": "forward"},
+		{"name": "even", "from": 2, "to": 5, "direction": "forward"},
+		{"name": "single", "from": 4, "to": 4, "direction": "forward"}`, ""))
+
+	expected := map[string]int{"": 2, "odd": 2, "even": 3, "single": 4}
+
+	for name, frame := range expected {
+		tag, _ := file.TagByName(name)
+		if thumbnail := tag.ThumbnailFrame(); thumbnail != frame {
+			t.Errorf("expected the thumbnail frame of tag %q to be %d, got %d", name, frame, thumbnail)
+		}
+	}
+
+	thumbnails := file.Thumbnails()
+
+	if len(thumbnails) != len(expected) {
+		t.Errorf("expected %d thumbnails, got %d", len(expected), len(thumbnails))
+	}
+
+	for name, frame := range expected {
+		if thumbnails[name] != frame {
+			t.Errorf("expected Thumbnails() to give tag %q frame %d, got %d", name, frame, thumbnails[name])
+		}
+	}
+
+}
+
+func TestOnUpdate(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 100}, "", ""))
+	player := file.CreatePlayer()
+	player.Play("")
+
+	calls := 0
+	total := float32(0)
+	player.OnUpdate = func(dt float32) {
+		calls++
+		total += dt
+	}
+
+	// Updates that don't change the frame still call OnUpdate.
+	for i := 0; i < 5; i++ {
+		player.Update(0.01)
+	}
+
+	if calls != 5 || player.FrameIndex != 0 {
+		t.Errorf("expected 5 calls without a frame change, got %d calls (frame %d)", calls, player.FrameIndex)
+	}
+
+	player.Pause()
+	player.Update(0.01)
+	player.AdvanceRealtime(0.01)
+
+	if calls != 7 {
+		t.Errorf("expected OnUpdate to be called while paused and by AdvanceRealtime(), got %d calls", calls)
+	}
+
+	if math.Abs(float64(total-0.07)) > 0.0001 {
+		t.Errorf("expected OnUpdate to be given each call's dt (totalling 0.07), got %f", total)
+	}
+
+}
+
+func TestLayerColor(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100}, "", `"layers": [
+		{"name": "Body", "opacity": 255, "blendMode": "normal", "color": "#fe5b59ff"},
+		{"name": "Shadow", "opacity": 255, "blendMode": "normal", "color": "#3c8aff"},
+		{"name": "Ground", "opacity": 255, "blendMode": "normal"}
+	]`))
+
+	expected := []int64{0xfe5b59ff, 0x3c8affff, 0}
+
+	for i, color := range expected {
+		if layer := file.Layers[i]; layer.Color != color {
+			t.Errorf("expected layer %q to have color %08x, got %08x", layer.Name, color, layer.Color)
+		}
+	}
+
+}
+
+func TestManifest(t *testing.T) {
+
+	file := readSheet(t, sheetJSON([]int{100, 250}, `{"name": "blink", "from": 0, "to": 1, "direction": "pingpong"}`, `"slices": [
+		{"name": "hitbox", "color": "#0000ffff", "keys": [{"frame": 0, "bounds": {"x": 0, "y": 0, "w": 4, "h": 4}}]}
+	]`))
+
+	data, err := json.Marshal(file.Manifest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := map[string]interface{}{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded["width"] != 32.0 || decoded["height"] != 16.0 || decoded["frameWidth"] != 16.0 || decoded["frameHeight"] != 16.0 {
+		t.Errorf("expected a 32x16 sheet of 16x16 frames, got %s", data)
+	}
+
+	manifest := Manifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(manifest.FrameDurations) != 2 || manifest.FrameDurations[0] != file.Frames[0].Duration || manifest.FrameDurations[1] != file.Frames[1].Duration {
+		t.Errorf("expected the frame durations to round-trip, got %v", manifest.FrameDurations)
+	}
+
+	found := false
+	for _, tag := range manifest.Tags {
+		if tag.Name == "blink" {
+			found = true
+			if tag.Start != 0 || tag.End != 1 || tag.Direction != PlayPingPong {
+				t.Errorf("expected blink to be a ping-pong tag spanning 0-1, got %d-%d %s", tag.Start, tag.End, tag.Direction)
+			}
+		}
+	}
+
+	if !found {
+		t.Errorf("expected the manifest to include the blink tag, got %s", data)
+	}
+
+	if len(manifest.Slices) != 1 || manifest.Slices[0] != "hitbox" {
+		t.Errorf("expected the manifest's slices to be [hitbox], got %v", manifest.Slices)
+	}
+
+}
+
+func TestReadErrors(t *testing.T) {
+
+	invalid := map[string]string{
+		"empty data":         "",
+		"no frames":          `{"meta": {"image": "sheet.png"}}`,
+		"empty frames":       `{"frames": {}, "meta": {"image": "sheet.png"}}`,
+		"frames as an array": `{"frames": [{"filename": "sheet 0.aseprite", "duration": 100}], "meta": {"image": "sheet.png"}}`,
+		"frames as a number": `{"frames": 3, "meta": {"image": "sheet.png"}}`,
+	}
+
+	for name, data := range invalid {
+		if file, err := Read([]byte(data)); !errors.Is(err, ErrInvalidJSON) || file != nil {
+			t.Errorf("%s: expected ErrInvalidJSON and no File, got %v", name, err)
+		}
+	}
+
+	outOfRange := map[string]string{
+		"past the last frame":  `{"name": "jump", "from": 5, "to": 7, "direction": "forward"}`,
+		"ending before start":  `{"name": "jump", "from": 1, "to": 0, "direction": "forward"}`,
+		"before the first one": `{"name": "jump", "from": -1, "to": 0, "direction": "forward"}`,
+	}
+
+	for name, tag := range outOfRange {
+		file, err := ReadString(sheetJSON([]int{100, 100}, tag, ""))
+		if !errors.Is(err, ErrFrameOutOfRange) || !strings.Contains(err.Error(), `"jump"`) || file != nil {
+			t.Errorf("%s: expected an error naming the jump tag and wrapping ErrFrameOutOfRange, got %v", name, err)
+		}
+	}
+
+	if file, err := Open("missing.json", os.DirFS(".")); err == nil || file != nil {
+		t.Errorf("expected an error and no File when opening a missing file, got %v", err)
+	}
+
+}